@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Answers files pre-fill the questions `laravel new` asks, so an interactive
+// session recorded with --record-answers can be replayed with --answers:
+//
+//	database: pgsql
+//	app_url: http://shop.test
+//	migrate: true
+//	npm: false
+//
+// Questions whose value is given as a flag are not asked and therefore
+// neither read from nor written to the answers file.
+
+// answerValidators lists every question key and checks its value.
+var answerValidators = map[string]func(string) error{
+	"database": validateDriverName,
+	"app_url": func(value string) error {
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("expected an absolute URL such as %s", defaultAppURL)
+		}
+		return nil
+	},
+	"broadcast":            validateServiceDriver("broadcast"),
+	"cache":                validateServiceDriver("cache"),
+	"create_database":      validateBoolAnswer,
+	"db_host":              validateRequiredAnswer,
+	"db_port":              validatePort,
+	"db_database":          validateRequiredAnswer,
+	"db_username":          validateRequiredAnswer,
+	"db_password":          func(string) error { return nil },
+	"generate_db_password": validateBoolAnswer,
+	"mail":                 validateServiceDriver("mail"),
+	"migrate":              validateBoolAnswer,
+	"migrate_unreachable":  validateBoolAnswer,
+	"npm":                  validateBoolAnswer,
+	"queue":                validateServiceDriver("queue"),
+	"services":             validateBoolAnswer,
+	"session":              validateServiceDriver("session"),
+}
+
+func validateRequiredAnswer(value string) error {
+	if value == "" {
+		return fmt.Errorf("expected a value")
+	}
+	return nil
+}
+
+func validateBoolAnswer(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("expected true or false")
+	}
+	return nil
+}
+
+// loadAnswers reads and validates an answers file. An empty path means no
+// answers were given.
+func loadAnswers(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	doc, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	answers := map[string]string{}
+	for _, entry := range doc.Entries {
+		validate, ok := answerValidators[entry.Key]
+		if !ok {
+			return nil, &confError{Path: path, Line: entry.Line, Msg: fmt.Sprintf("unknown question %q", entry.Key)}
+		}
+		if entry.IsList {
+			return nil, &confError{Path: path, Line: entry.Line, Msg: fmt.Sprintf("%s expects a single value", entry.Key)}
+		}
+		if err := validate(entry.Value); err != nil {
+			return nil, &confError{Path: path, Line: entry.Line, Msg: fmt.Sprintf("invalid value %q for %s: %v", entry.Value, entry.Key, err)}
+		}
+		answers[entry.Key] = entry.Value
+	}
+	return answers, nil
+}
+
+// saveAnswers writes the recorded answers in the format matching the file
+// extension: YAML for .yaml/.yml, TOML otherwise.
+func saveAnswers(path, projectName string, recorded []confEntry) error {
+	separator := " = "
+	if isYAMLPath(path) {
+		separator = ": "
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Answers recorded by `laravel new %s`. Replay them with --answers=%s\n", projectName, filepath.Base(path))
+	for _, entry := range recorded {
+		fmt.Fprintf(&b, "%s%s%s\n", entry.Key, separator, formatConfigValue(path, entry.Value))
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yaml")
+	writeTestFile(path, "# Onboarding\ndatabase: pgsql\napp_url: \"http://shop.test\"\nmigrate: true\nnpm: false\n")
+
+	answers, err := loadAnswers(path)
+	if err != nil {
+		t.Fatalf("loadAnswers returned error: %v", err)
+	}
+	want := map[string]string{"database": "pgsql", "app_url": "http://shop.test", "migrate": "true", "npm": "false"}
+	for key, value := range want {
+		if answers[key] != value {
+			t.Errorf("Expected %s = %q, got %q", key, value, answers[key])
+		}
+	}
+}
+
+func TestLoadAnswersErrors(t *testing.T) {
+	testCases := []struct {
+		data string
+		want string
+	}{
+		{"database: pgsql\ndatabse: mysql\n", "answers.yaml:2: unknown question \"databse\""},
+		{"npm: true\ndatabase: oracle\n", "answers.yaml:2: invalid value \"oracle\" for database: possible values are"},
+		{"app_url: shop.test\n", "answers.yaml:1: invalid value \"shop.test\" for app_url"},
+		{"migrate: sometimes\n", "answers.yaml:1: invalid value \"sometimes\" for migrate"},
+		{"database:\n  - pgsql\n", "answers.yaml:1: database expects a single value"},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "answers.yaml")
+		writeTestFile(path, tc.data)
+		_, err := loadAnswers(path)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("loadAnswers(%q) error = %v; want %q", tc.data, err, tc.want)
+		}
+	}
+}
+
+func TestRecordAndReplayAnswers(t *testing.T) {
+	// PostgreSQL with the default connection settings and a typed password,
+	// a custom URL, no migrations and the npm build
+	fake := useFakeRunner(t, "4\n\n\n\n\ns3cret\nhttp://shop.test\nn\ny\n")
+	quiet, recordAnswers = true, "answers.yaml"
+
+	if err := createNewProject("first"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	recorded, err := os.ReadFile("answers.yaml")
+	if err != nil {
+		t.Fatalf("Expected the answers to be recorded: %v", err)
+	}
+	for _, line := range []string{"database: pgsql", "db_port: 5432", "db_database: first", "app_url: \"http://shop.test\"", "migrate: false", "npm: true"} {
+		if !strings.Contains(string(recorded), line+"\n") {
+			t.Errorf("Expected the recorded answers to contain %q, got:\n%s", line, recorded)
+		}
+	}
+	if strings.Contains(string(recorded), "s3cret") {
+		t.Errorf("Expected the password not to be recorded, got:\n%s", recorded)
+	}
+
+	// Replay without any input
+	dir, _ := os.Getwd()
+	fake = useFakeRunner(t, "")
+	quiet, noInteraction, answersFile = true, true, filepath.Join(dir, "answers.yaml")
+
+	if err := createNewProject("second"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	env, _ := os.ReadFile(filepath.Join("second", ".env"))
+	for _, line := range []string{"DB_CONNECTION=pgsql", "APP_URL=http://shop.test"} {
+		if !strings.Contains(string(env), line) {
+			t.Errorf("Expected .env to contain %s", line)
+		}
+	}
+	commands := strings.Join(fake.commandLines(), "\n")
+	if !strings.Contains(commands, "npm install") || strings.Contains(commands, "migrate") {
+		t.Errorf("Expected the replayed answers to run npm but not the migrations, got:\n%s", commands)
+	}
+}
+
+func TestInvalidAnswersFileIsUsageError(t *testing.T) {
+	useFakeRunner(t, "")
+	writeTestFile("answers.yaml", "database: oracle\n")
+	answersFile = "answers.yaml"
+
+	if code := exitCodeFor(createNewProject("demo")); code != exitUsage {
+		t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+	}
+	if _, err := os.Stat("demo"); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be created for an invalid answers file")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+
+	"github.com/spf13/cobra"
+)
+
+// bundleMemberPattern matches the files a bundle may contain: the archives of
+// the skeleton cache and their .json files, at the same paths as in the cache.
+var bundleMemberPattern = regexp.MustCompile(`^skeletons/[a-z0-9][a-z0-9_.-]*/[a-z0-9][a-z0-9_.-]*/[^/.][^/]*\.(tar\.gz|json)$`)
+
+// exportBundle writes the cache entries to w as a tarball.
+func exportBundle(w io.Writer, entries []*cachedSkeleton) error {
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		// The archive goes first, so an interrupted import never leaves a
+		// .json file without its archive
+		for _, file := range []string{entry.Archive, skeletonMetadataPath(entry.Archive)} {
+			if err := addBundleFile(tw, file); err != nil {
+				return err
+			}
+		}
+	}
+	return tw.Close()
+}
+
+func addBundleFile(tw *tar.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(userCacheDir(), file)
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:    filepath.ToSlash(rel),
+		Mode:    0644,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// importBundle copies the skeletons in a bundle into the cache and returns
+// what it imported. Gzipped bundles are accepted too.
+func importBundle(r io.Reader) ([]*cachedSkeleton, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var imported []*cachedSkeleton
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return imported, nil
+		}
+		if err != nil {
+			return imported, fmt.Errorf("not a bundle: %w", err)
+		}
+		if header.Typeflag == tar.TypeDir {
+			continue
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || !bundleMemberPattern.MatchString(name) {
+			return imported, fmt.Errorf("unexpected file %s in the bundle", header.Name)
+		}
+
+		target := filepath.Join(userCacheDir(), filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return imported, err
+		}
+		if path.Ext(name) == ".json" {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return imported, err
+			}
+			entry := &cachedSkeleton{}
+			if err := json.Unmarshal(data, entry); err != nil {
+				return imported, fmt.Errorf("%s: %w", header.Name, err)
+			}
+			if err := os.WriteFile(target, data, 0644); err != nil {
+				return imported, err
+			}
+			imported = append(imported, entry)
+			continue
+		}
+		if err := writeAtomically(target, func(w io.Writer) error {
+			_, err := io.Copy(w, tr)
+			return err
+		}); err != nil {
+			return imported, err
+		}
+	}
+}
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Carry the skeleton cache to hosts without network access",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var bundleExportCmd = &cobra.Command{
+	Use:   "export <file> [package[:version]...]",
+	Short: "Write the cached skeletons to a tarball",
+	Example: "  laravel bundle export laravel-skeletons.tar\n" +
+		"  laravel bundle export react.tar laravel/react-starter-kit",
+	Args:         usageArgs(cobra.MinimumNArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		wanted := map[string]bool{}
+		for _, arg := range args[1:] {
+			pkg, version, err := parsePackageArg(arg)
+			if err != nil {
+				return newExitError(exitUsage, err)
+			}
+			wanted[pkg+":"+version] = true
+			if version == latestSkeleton {
+				wanted[pkg] = true // Every version of the package
+			}
+		}
+
+		entries, err := cachedSkeletons()
+		if err != nil {
+			return err
+		}
+		var selected []*cachedSkeleton
+		for _, entry := range entries {
+			if len(wanted) == 0 || wanted[entry.Package] || wanted[entry.Package+":"+entry.Version] {
+				selected = append(selected, entry)
+			}
+		}
+		if len(selected) == 0 {
+			return exitErrorf(exitFailure, "There are no cached skeletons to export. Run laravel cache warm first.")
+		}
+
+		if err := writeAtomically(args[0], func(w io.Writer) error {
+			return exportBundle(w, selected)
+		}); err != nil {
+			return err
+		}
+		var size int64
+		for _, entry := range selected {
+			fmt.Fprintf(stdout, "Added %s %s\n", entry.Package, entry.Version)
+			size += entry.Size
+		}
+		fmt.Fprintf(stdout, "Exported %d skeletons (%s) to %s\n", len(selected), formatSize(size), args[0])
+		return nil
+	},
+}
+
+var bundleImportCmd = &cobra.Command{
+	Use:          "import <file>",
+	Short:        "Add the skeletons in a tarball to the cache",
+	Example:      "  laravel bundle import laravel-skeletons.tar",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return newExitError(exitUsage, err)
+		}
+		defer f.Close()
+
+		imported, err := importBundle(f)
+		for _, entry := range imported {
+			fmt.Fprintf(stdout, "Imported %s %s\n", entry.Package, entry.Version)
+		}
+		if err != nil {
+			return exitErrorf(exitFailure, "could not import %s: %w", args[0], err)
+		}
+		fmt.Fprintf(stdout, "Imported %d skeletons into %s\n", len(imported), skeletonCacheDir())
+		return nil
+	},
+}
+
+func init() {
+	bundleCmd.AddCommand(bundleExportCmd, bundleImportCmd)
+	rootCmd.AddCommand(bundleCmd)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleExportAndImport(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	warmCache(t, "laravel/laravel", "laravel/laravel:dev-master", "acme/skeleton")
+
+	var out bytes.Buffer
+	stdout = &out
+	defer func() { stdout = os.Stdout }()
+	bundle := filepath.Join(t.TempDir(), "skeletons.tar")
+	if err := bundleExportCmd.RunE(bundleExportCmd, []string{bundle, "laravel/laravel"}); err != nil {
+		t.Fatalf("bundle export returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Exported 2 skeletons") {
+		t.Errorf("Expected every version of laravel/laravel to be exported, got %s", out.String())
+	}
+
+	// An air-gapped host with an empty cache
+	useSkeletonCache(t, fake)
+	out.Reset()
+	if err := bundleImportCmd.RunE(bundleImportCmd, []string{bundle}); err != nil {
+		t.Fatalf("bundle import returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "Imported laravel/laravel dev-master") || !strings.Contains(out.String(), "Imported 2 skeletons") {
+		t.Errorf("Unexpected import output: %s", out.String())
+	}
+	entries, _ := cachedSkeletons()
+	if len(entries) != 2 || entries[0].Framework != "v12.3.0" {
+		t.Fatalf("Expected the imported skeletons in the cache, got %v", entries)
+	}
+
+	fake.commands = nil
+	quiet, noInteraction, offline, dev = true, true, true, true
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join("demo", "artisan")); err != nil {
+		t.Errorf("Expected the project to be created from the imported skeleton: %v", err)
+	}
+}
+
+func TestBundleExportWithEmptyCache(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+
+	err := bundleExportCmd.RunE(bundleExportCmd, []string{filepath.Join(t.TempDir(), "skeletons.tar")})
+	if err == nil || !strings.Contains(err.Error(), "laravel cache warm") {
+		t.Errorf("Expected an empty cache to be reported, got %v", err)
+	}
+}
+
+func TestImportBundleRejectsUnexpectedFiles(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	cache := useSkeletonCache(t, fake)
+
+	for _, name := range []string{"skeletons/../../evil.tar.gz", "skeletons/laravel/laravel/latest.php", "config.toml"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: 1, Typeflag: tar.TypeReg})
+		tw.Write([]byte("x"))
+		tw.Close()
+
+		if _, err := importBundle(&buf); err == nil || !strings.Contains(err.Error(), "unexpected file") {
+			t.Errorf("Expected %s to be rejected, got %v", name, err)
+		}
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written to the cache")
+	}
+}
@@ -0,0 +1,630 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	offline         bool
+	cacheRepository string
+	cachePruneAll   bool
+	cachePruneDays  int
+)
+
+// latestSkeleton is the version the cache files an archive under when no
+// version was asked for.
+const latestSkeleton = "latest"
+
+// composerPackagePattern matches the vendor/name of a Composer package, which
+// is also its directory in the cache.
+var composerPackagePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]*/[a-z0-9][a-z0-9_.-]*$`)
+
+// cachedSkeleton describes a skeleton archive in the cache. It is stored next
+// to the archive as a .json file.
+type cachedSkeleton struct {
+	Package   string    `json:"package"`
+	Version   string    `json:"version"`             // The version asked for, "latest" when none was
+	Framework string    `json:"framework,omitempty"` // The laravel/framework version in composer.lock
+	Created   time.Time `json:"created"`
+	Size      int64     `json:"-"`
+	Archive   string    `json:"-"`
+}
+
+// userCacheDir returns the directory holding the skeleton cache. It honours
+// XDG_CACHE_HOME and falls back to ~/.cache/laravel, also on macOS.
+func userCacheDir() string {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "laravel")
+	}
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserCacheDir(); err == nil {
+			return filepath.Join(dir, "laravel")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".cache", "laravel")
+}
+
+func skeletonCacheDir() string {
+	return filepath.Join(userCacheDir(), "skeletons")
+}
+
+// skeletonArchive returns the path of the archive for a package and version.
+func skeletonArchive(pkg, version string) string {
+	return filepath.Join(skeletonCacheDir(), filepath.FromSlash(pkg), url.PathEscape(version)+".tar.gz")
+}
+
+func skeletonMetadataPath(archive string) string {
+	return strings.TrimSuffix(archive, ".tar.gz") + ".json"
+}
+
+// skeletonSource returns the package and version `laravel new` creates the
+// project from, which is what the cache is keyed by.
+func skeletonSource(starterKit, version string) (string, string) {
+	args := createProjectArgs("project", starterKit, version)
+	pkg, ver, _ := strings.Cut(args[1], ":")
+	if starterKit == "" {
+		ver = version
+	}
+	if ver == "" {
+		ver = latestSkeleton
+	}
+	return pkg, ver
+}
+
+// parsePackageArg splits vendor/name[:version] into the package and version.
+func parsePackageArg(arg string) (string, string, error) {
+	pkg, version, _ := strings.Cut(arg, ":")
+	if !composerPackagePattern.MatchString(pkg) {
+		return "", "", fmt.Errorf("invalid package [%s]: expected vendor/name[:version]", arg)
+	}
+	if version == "" {
+		version = latestSkeleton
+	}
+	return pkg, version, nil
+}
+
+// warmSkeleton creates the package with composer in a temporary directory and
+// stores it, dependencies included, as an archive in the cache.
+func warmSkeleton(ctx context.Context, pkg, version string) (*cachedSkeleton, error) {
+	tmp, err := os.MkdirTemp("", "laravel-skeleton-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "skeleton")
+	args := []string{"create-project", pkg, dir}
+	if version != latestSkeleton {
+		args[1] = pkg + ":" + version
+	}
+	if pkg != "laravel/laravel" {
+		args = append(args, "--stability=dev")
+	}
+	args = append(args, "--remove-vcs", "--prefer-dist", "--no-scripts", "--no-interaction")
+	if cacheRepository != "" {
+		args = append(args, "--repository="+composerRepository(cacheRepository))
+	}
+	if _, err := runCommand(ctx, newCommand("", "composer", args...)); err != nil {
+		return nil, fmt.Errorf("could not download %s: %w", pkg, err)
+	}
+
+	entry := &cachedSkeleton{
+		Package:   pkg,
+		Version:   version,
+		Framework: lockedVersion(dir, "laravel/framework"),
+		Created:   time.Now().UTC(),
+		Archive:   skeletonArchive(pkg, version),
+	}
+	if err := os.MkdirAll(filepath.Dir(entry.Archive), 0755); err != nil {
+		return nil, err
+	}
+	if err := writeAtomically(entry.Archive, func(w io.Writer) error {
+		return archiveDirectory(dir, w)
+	}); err != nil {
+		return nil, fmt.Errorf("could not write %s: %w", entry.Archive, err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(skeletonMetadataPath(entry.Archive), append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(entry.Archive); err == nil {
+		entry.Size = info.Size()
+	}
+	return entry, nil
+}
+
+// composerRepository turns a local directory into a path repository, so
+// packages can be cached from a checkout instead of Packagist. Anything else
+// is passed to composer as is.
+func composerRepository(repository string) string {
+	if !isDir(repository) {
+		return repository
+	}
+	abs, err := filepath.Abs(repository)
+	if err != nil {
+		abs = repository
+	}
+	data, _ := json.Marshal(map[string]any{
+		"type":    "path",
+		"url":     filepath.ToSlash(abs),
+		"options": map[string]bool{"symlink": false},
+	})
+	return string(data)
+}
+
+// lockedVersion returns the version of pkg in the composer.lock of dir.
+func lockedVersion(dir, pkg string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "composer.lock"))
+	if err != nil {
+		return ""
+	}
+	var lock struct {
+		Packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if json.Unmarshal(data, &lock) != nil {
+		return ""
+	}
+	for _, p := range lock.Packages {
+		if p.Name == pkg {
+			return p.Version
+		}
+	}
+	return ""
+}
+
+// cachedSkeletons returns the archives in the cache, sorted by package and
+// version.
+func cachedSkeletons() ([]*cachedSkeleton, error) {
+	var entries []*cachedSkeleton
+	err := filepath.WalkDir(skeletonCacheDir(), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		entry := &cachedSkeleton{}
+		if err := json.Unmarshal(data, entry); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		entry.Archive = strings.TrimSuffix(path, ".json") + ".tar.gz"
+		info, err := os.Stat(entry.Archive)
+		if err != nil {
+			return nil // The archive is still being written, or was removed by hand
+		}
+		entry.Size = info.Size()
+		entries = append(entries, entry)
+		return nil
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Package != entries[j].Package {
+			return entries[i].Package < entries[j].Package
+		}
+		return entries[i].Version < entries[j].Version
+	})
+	return entries, err
+}
+
+// installCachedSkeleton creates the project from the cache instead of with
+// composer create-project.
+func installCachedSkeleton(ctx context.Context, projectName, starterKit, version string) error {
+	pkg, ver := skeletonSource(starterKit, version)
+	archive := skeletonArchive(pkg, ver)
+	if _, err := os.Stat(archive); err != nil {
+		return fmt.Errorf("%s %s is not in the offline cache (%s); run `laravel cache warm` while online or import a bundle with `laravel bundle import`", pkg, ver, userCacheDir())
+	}
+	if !quiet {
+		fmt.Printf("Installing Laravel from the offline cache (%s %s)...\n", pkg, ver)
+	}
+
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := extractArchive(f, projectName); err != nil {
+		return fmt.Errorf("could not extract %s: %w", archive, err)
+	}
+
+	// composer create-project runs the scripts of starter kits, but the
+	// archive was made without them
+	if starterKit == "" {
+		return nil
+	}
+	for _, cmdArgs := range starterKitScripts(projectName) {
+		if _, err := runCommand(ctx, newCommand("", cmdArgs[0], cmdArgs[1:]...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// starterKitScripts returns the scripts composer create-project would have
+// run for a starter kit.
+func starterKitScripts(projectDir string) [][]string {
+	return [][]string{
+		{"composer", "run", "post-root-package-install", "-d", projectDir},
+		{"composer", "run", "post-create-project-cmd", "-d", projectDir},
+	}
+}
+
+// validateOfflineFlags rejects the options that download packages, which an
+// --offline installation cannot do.
+func validateOfflineFlags() error {
+	if !offline {
+		return nil
+	}
+	var given []string
+	if pest {
+		given = append(given, "--pest")
+	}
+	if manager := selectedPackageManager(); manager != nil {
+		given = append(given, "--"+manager.Name)
+	}
+	if github != "" {
+		given = append(given, "--github")
+	}
+	if d := lookupDriver(database); d != nil && len(d.Packages) > 0 {
+		given = append(given, "--database="+database)
+	}
+	for _, s := range appServices {
+		if d := s.driver(*s.Flag); d != nil && len(d.Packages) > 0 {
+			given = append(given, fmt.Sprintf("--%s=%s", s.Key, *s.Flag))
+		}
+	}
+	if activePreset != nil && len(activePreset.Packages)+len(activePreset.DevPackages) > 0 {
+		given = append(given, "--preset="+activePreset.Name)
+	}
+	if len(given) > 0 {
+		return fmt.Errorf("--offline cannot be combined with %s, which download packages or talk to GitHub", strings.Join(given, ", "))
+	}
+	return nil
+}
+
+// writeAtomically writes path through a temporary file in the same
+// directory, so a failed write never leaves a truncated file behind.
+func writeAtomically(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// archiveDirectory writes dir to w as a gzipped tarball, keeping file modes
+// and the symlinks in vendor/bin.
+func archiveDirectory(dir string, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		link := ""
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		header.Name = filepath.ToSlash(rel)
+		if d.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// extractArchive unpacks a gzipped tarball into dir. Entries and symlinks
+// that would end up outside dir are refused.
+func extractArchive(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	root, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// The path is checked once more with the symlinks extracted so far
+		// followed, so a chain of links cannot lead out of dir
+		target, ok := archiveTarget(dir, header.Name)
+		if !ok || !withinDir(root, resolveArchivePath(root, header.Name)) {
+			return fmt.Errorf("refusing to extract %s outside %s", header.Name, dir)
+		}
+		mode := fs.FileMode(header.Mode).Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, mode|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			link := filepath.FromSlash(header.Linkname)
+			if filepath.IsAbs(link) {
+				return fmt.Errorf("refusing to extract the absolute symlink %s", header.Name)
+			}
+			parent := resolveArchivePath(root, path.Dir(header.Name))
+			if !withinDir(root, resolveArchivePath(parent, header.Linkname)) {
+				return fmt.Errorf("refusing to extract %s, which points outside %s", header.Name, dir)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := os.Symlink(link, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// archiveTarget returns where the archive entry name goes in dir, and false
+// when it would end up outside of it.
+func archiveTarget(dir, name string) (string, bool) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.Join(dir, clean), true
+}
+
+// resolveArchivePath returns where the slash-separated name leads from base,
+// following the symlinks that already exist. The part that does not exist
+// yet is joined as is.
+func resolveArchivePath(base, name string) string {
+	current := base
+	for _, part := range strings.Split(name, "/") {
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+		current = filepath.Join(current, part)
+		if resolved, err := filepath.EvalSymlinks(current); err == nil {
+			current = resolved
+		}
+	}
+	return current
+}
+
+// withinDir reports whether target is dir or inside it.
+func withinDir(dir, target string) bool {
+	rel, err := filepath.Rel(dir, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// formatSize formats a byte count for humans.
+func formatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the offline cache of application skeletons",
+	Long: "Manage the cache `laravel new --offline` creates applications from. Each package and\n" +
+		"version is stored as an archive, dependencies included, in the user cache directory\n" +
+		"($XDG_CACHE_HOME/laravel or ~/.cache/laravel).",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm [package[:version]...]",
+	Short: "Download skeletons and starter kits into the cache",
+	Long: "Download skeletons and starter kits into the cache. Without packages, the skeleton\n" +
+		"`laravel new` would use with the same options is cached.",
+	Example: "  laravel cache warm\n" +
+		"  laravel cache warm --react --dev\n" +
+		"  laravel cache warm laravel/laravel:^12.0 laravel/vue-starter-kit\n" +
+		"  laravel cache warm acme/skeleton --repository=../skeleton",
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		type source struct{ pkg, version string }
+		var sources []source
+		for _, arg := range args {
+			pkg, version, err := parsePackageArg(arg)
+			if err != nil {
+				return newExitError(exitUsage, err)
+			}
+			sources = append(sources, source{pkg, version})
+		}
+		if len(sources) == 0 {
+			pkg, version := skeletonSource(getStarterKit(), getVersion())
+			sources = append(sources, source{pkg, version})
+		}
+
+		ctx := cmd.Context()
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		for _, s := range sources {
+			entry, err := warmSkeleton(ctx, s.pkg, s.version)
+			if err != nil {
+				return newExitError(exitFailure, err)
+			}
+			framework := ""
+			if entry.Framework != "" {
+				framework = ", Laravel " + entry.Framework
+			}
+			fmt.Fprintf(stdout, "Cached %s %s (%s%s)\n", entry.Package, entry.Version, formatSize(entry.Size), framework)
+		}
+		return nil
+	},
+}
+
+var cacheListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the cached skeletons",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		entries, err := cachedSkeletons()
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			fmt.Fprintf(stdout, "The cache in %s is empty. Run laravel cache warm to fill it.\n", skeletonCacheDir())
+			return nil
+		}
+		for _, entry := range entries {
+			framework := entry.Framework
+			if framework == "" {
+				framework = "-"
+			}
+			fmt.Fprintf(stdout, "%-32s %-16s %-10s %9s  %s\n", entry.Package, entry.Version, framework, formatSize(entry.Size), entry.Created.Local().Format("2006-01-02"))
+		}
+		return nil
+	},
+}
+
+var cachePruneCmd = &cobra.Command{
+	Use:          "prune",
+	Short:        "Remove old skeletons from the cache",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cachePruneDays < 0 {
+			return exitErrorf(exitUsage, "--older-than must be 0 or more days")
+		}
+		entries, err := cachedSkeletons()
+		if err != nil {
+			return err
+		}
+
+		cutoff := time.Now().Add(-time.Duration(cachePruneDays) * 24 * time.Hour)
+		removed, freed := 0, int64(0)
+		for _, entry := range entries {
+			if !cachePruneAll && entry.Created.After(cutoff) {
+				continue
+			}
+			if err := os.Remove(entry.Archive); err != nil {
+				return err
+			}
+			os.Remove(skeletonMetadataPath(entry.Archive))
+			os.Remove(filepath.Dir(entry.Archive)) // Only succeeds once the package has no versions left
+			fmt.Fprintf(stdout, "Removed %s %s\n", entry.Package, entry.Version)
+			removed++
+			freed += entry.Size
+		}
+		fmt.Fprintf(stdout, "Removed %d skeletons, freeing %s\n", removed, formatSize(freed))
+		return nil
+	},
+}
+
+func init() {
+	cacheWarmCmd.Flags().StringVar(&cacheRepository, "repository", "", "Download from this Composer repository (a URL, JSON or a local directory) as well as Packagist")
+	cachePruneCmd.Flags().IntVar(&cachePruneDays, "older-than", 30, "Remove the skeletons cached more than this many days ago")
+	cachePruneCmd.Flags().BoolVar(&cachePruneAll, "all", false, "Remove every skeleton")
+
+	cacheCmd.AddCommand(cacheWarmCmd, cacheListCmd, cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useSkeletonCache points the skeleton cache at a temporary directory and
+// makes composer create-project write a skeleton with a lockfile and a
+// vendor/bin symlink, as the real one does.
+func useSkeletonCache(t *testing.T, fake *fakeRunner) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	cacheRepository, cachePruneAll, cachePruneDays = "", false, 30
+	t.Cleanup(func() { cacheRepository, cachePruneAll, cachePruneDays = "", false, 30 })
+
+	fake.handler = func(cmd Command) (*Result, error) {
+		if cmd.Name == "composer" && len(cmd.Args) > 2 && cmd.Args[0] == "create-project" {
+			fakeSkeletonHandler(cmd)
+			project := filepath.Join(cmd.Dir, cmd.Args[2])
+			os.WriteFile(filepath.Join(project, "composer.lock"), []byte(`{"packages": [{"name": "laravel/framework", "version": "v12.3.0"}]}`), 0644)
+			os.MkdirAll(filepath.Join(project, "vendor", "pestphp", "pest", "bin"), 0755)
+			os.WriteFile(filepath.Join(project, "vendor", "pestphp", "pest", "bin", "pest"), []byte("#!/usr/bin/env php\n"), 0755)
+			os.MkdirAll(filepath.Join(project, "vendor", "bin"), 0755)
+			os.Symlink(filepath.Join("..", "pestphp", "pest", "bin", "pest"), filepath.Join(project, "vendor", "bin", "pest"))
+			return &Result{}, nil
+		}
+		return fakeSkeletonHandler(cmd)
+	}
+	return filepath.Join(dir, "laravel", "skeletons")
+}
+
+func warmCache(t *testing.T, args ...string) string {
+	t.Helper()
+
+	var out bytes.Buffer
+	stdout = &out
+	defer func() { stdout = os.Stdout }()
+	if err := cacheWarmCmd.RunE(cacheWarmCmd, args); err != nil {
+		t.Fatalf("cache warm returned error: %v", err)
+	}
+	return out.String()
+}
+
+func TestOfflineProjectFromTheCache(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	cache := useSkeletonCache(t, fake)
+
+	if out := warmCache(t); !strings.Contains(out, "Cached laravel/laravel latest") || !strings.Contains(out, "Laravel v12.3.0") {
+		t.Errorf("Unexpected warm output: %s", out)
+	}
+	if _, err := os.Stat(filepath.Join(cache, "laravel", "laravel", "latest.tar.gz")); err != nil {
+		t.Fatalf("Expected the archive in the cache: %v", err)
+	}
+
+	var out bytes.Buffer
+	stdout = &out
+	if err := cacheListCmd.RunE(cacheListCmd, nil); err != nil {
+		t.Fatalf("cache list returned error: %v", err)
+	}
+	stdout = os.Stdout
+	if !strings.Contains(out.String(), "laravel/laravel") || !strings.Contains(out.String(), "v12.3.0") {
+		t.Errorf("Expected the skeleton to be listed, got %s", out.String())
+	}
+
+	fake.commands = nil
+	quiet, noInteraction, offline = true, true, true
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	for _, line := range fake.commandLines() {
+		if strings.Contains(line, "create-project") {
+			t.Errorf("Expected no download when offline, got %s", line)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("demo", "artisan")); err != nil {
+		t.Errorf("Expected the skeleton to be extracted: %v", err)
+	}
+	if link, err := os.Readlink(filepath.Join("demo", "vendor", "bin", "pest")); err != nil || link != filepath.Join("..", "pestphp", "pest", "bin", "pest") {
+		t.Errorf("Expected the vendor/bin symlink to survive, got %q (%v)", link, err)
+	}
+	if info, err := os.Stat(filepath.Join("demo", "vendor", "pestphp", "pest", "bin", "pest")); err != nil || info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected the file mode to survive, got %v (%v)", info, err)
+	}
+}
+
+func TestOfflineWithoutTheSkeleton(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	quiet, noInteraction, offline, dev = true, true, true, true
+
+	err := createNewProject("demo")
+	if exitCodeFor(err) != exitProjectCreation {
+		t.Fatalf("Expected exit code %d, got %v", exitProjectCreation, err)
+	}
+	if !strings.Contains(err.Error(), "laravel/laravel dev-master is not in the offline cache") || !strings.Contains(err.Error(), "laravel cache warm") {
+		t.Errorf("Expected the error to say how to fill the cache, got %v", err)
+	}
+}
+
+func TestOfflineRefusesDownloads(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	warmCache(t)
+
+	tests := []struct {
+		set  func()
+		flag string
+	}{
+		{func() { pest = true }, "--pest"},
+		{func() { pnpm = true }, "--pnpm"},
+		{func() { github = "private" }, "--github"},
+		{func() { queueConnection = "sqs" }, "--queue=sqs"},
+		{func() { database = "mongodb" }, "--database=mongodb"},
+		{func() { activePreset = &preset{Name: "shop", Packages: []string{"laravel/cashier"}} }, "--preset=shop"},
+	}
+	for _, tt := range tests {
+		resetNewFlags()
+		fake.commands = nil
+		quiet, noInteraction, offline = true, true, true
+		tt.set()
+
+		err := createNewProject("demo")
+		if exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), "--offline cannot be combined with "+tt.flag) {
+			t.Errorf("Expected %s to be refused, got %v", tt.flag, err)
+		}
+		if len(fake.commands) != 0 {
+			t.Errorf("Expected nothing to run with %s, got %v", tt.flag, fake.commandLines())
+		}
+	}
+}
+
+func TestOfflineSkipsDownloadsChosenAtThePrompts(t *testing.T) {
+	// SQLite, the default URL, no migrations and, without the frontend
+	// question, the Redis cache, which needs predis without the extension
+	fake := useFakeRunner(t, "\n\nn\ny\n3\n\n\n\n\n")
+	useSkeletonCache(t, fake)
+	warmCache(t)
+	fake.commands = nil
+	offline = true
+	handler := fake.handler
+	fake.handler = func(cmd Command) (*Result, error) {
+		if cmd.String() == "php -m" {
+			// No redis extension, so Redis needs predis
+			return &Result{Stdout: []byte("[PHP Modules]\n" + strings.Join(laravelExtensions, "\n") + "\npdo_sqlite\n")}, nil
+		}
+		return handler(cmd)
+	}
+
+	var out bytes.Buffer
+	promptOut, stdout = &out, &out
+	defer func() { promptOut, stdout = os.Stdout, os.Stdout }()
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	if strings.Contains(out.String(), "npm install") {
+		t.Errorf("Expected no frontend question offline, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Warning: --offline does not download packages; run composer require predis/predis once online") {
+		t.Errorf("Expected a warning about predis, got:\n%s", out.String())
+	}
+	for _, cmd := range fake.commands {
+		if cmd.Name == "composer" && (cmd.Args[0] == "require" || cmd.Args[0] == "run" && !contains(cmd.Env, "COMPOSER_DISABLE_NETWORK=1")) {
+			t.Errorf("Expected composer to stay offline, got %s %v", cmd, cmd.Env)
+		}
+	}
+	env, _ := readTestFile(filepath.Join("demo", ".env"))
+	if !strings.Contains(env, "CACHE_STORE=redis") {
+		t.Errorf("Expected the Redis cache to be configured, got:\n%s", env)
+	}
+}
+
+func TestOfflinePlan(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	offline = true
+
+	plan := buildPlan("demo", newPrompter(nil))
+	create := plan.Steps[1]
+	if len(create.Commands) != 0 || len(create.Files) != 1 || !strings.HasPrefix(create.Files[0], "extract "+skeletonArchive("laravel/laravel", latestSkeleton)) {
+		t.Errorf("Expected the skeleton to be extracted from the cache, got %+v", create)
+	}
+	if !contains(plan.Warnings, "laravel/laravel latest is not in the offline cache") {
+		t.Errorf("Expected a warning about the missing skeleton, got %v", plan.Warnings)
+	}
+}
+
+func TestOfflineStarterKitRunsItsScripts(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	react, workos = true, true
+
+	if out := warmCache(t); !strings.Contains(out, "Cached laravel/react-starter-kit dev-workos") {
+		t.Fatalf("Unexpected warm output: %s", out)
+	}
+	warm := strings.Join(fake.commandLines(), "\n")
+	if !strings.Contains(warm, "create-project laravel/react-starter-kit:dev-workos ") || !strings.Contains(warm, "--stability=dev --remove-vcs --prefer-dist --no-scripts --no-interaction") {
+		t.Errorf("Unexpected warm command: %s", warm)
+	}
+
+	fake.commands = nil
+	quiet, noInteraction, offline = true, true, true
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	commands := strings.Join(fake.commandLines(), "\n")
+	if !strings.Contains(commands, "composer run post-root-package-install -d shop\ncomposer run post-create-project-cmd -d shop") {
+		t.Errorf("Expected the starter kit scripts to run, got:\n%s", commands)
+	}
+}
+
+func TestCacheWarmPackages(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	repository := t.TempDir()
+	cacheRepository = repository
+
+	warmCache(t, "laravel/laravel:^12.0", "acme/skeleton")
+	commands := fake.commandLines()
+	if len(commands) != 2 || !strings.HasPrefix(commands[0], "composer create-project 'laravel/laravel:^12.0' ") || strings.Contains(commands[0], "--stability") {
+		t.Errorf("Unexpected commands: %v", commands)
+	}
+	want := `--repository={"options":{"symlink":false},"type":"path","url":"` + filepath.ToSlash(repository) + `"}`
+	if args := fake.commands[1].Args; args[len(args)-1] != want {
+		t.Errorf("Expected the directory to become a path repository, got %s", commands[1])
+	}
+	if _, err := os.Stat(skeletonArchive("laravel/laravel", "^12.0")); err != nil {
+		t.Errorf("Expected the version to be cached: %v", err)
+	}
+
+	if err := cacheWarmCmd.RunE(cacheWarmCmd, []string{"../escape"}); exitCodeFor(err) != exitUsage {
+		t.Errorf("Expected an invalid package to be rejected, got %v", err)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	useSkeletonCache(t, fake)
+	warmCache(t, "laravel/laravel", "acme/skeleton")
+
+	// Age one of the entries
+	old := skeletonMetadataPath(skeletonArchive("acme/skeleton", latestSkeleton))
+	entry := &cachedSkeleton{Package: "acme/skeleton", Version: latestSkeleton, Created: time.Now().AddDate(0, 0, -45)}
+	data, _ := json.Marshal(entry)
+	os.WriteFile(old, data, 0644)
+
+	var out bytes.Buffer
+	stdout = &out
+	defer func() { stdout = os.Stdout }()
+	if err := cachePruneCmd.RunE(cachePruneCmd, nil); err != nil {
+		t.Fatalf("cache prune returned error: %v", err)
+	}
+	entries, _ := cachedSkeletons()
+	if len(entries) != 1 || entries[0].Package != "laravel/laravel" {
+		t.Errorf("Expected only the old skeleton to be removed, got %v", entries)
+	}
+	if _, err := os.Stat(filepath.Dir(old)); !os.IsNotExist(err) {
+		t.Errorf("Expected the empty package directory to be removed")
+	}
+
+	cachePruneAll = true
+	if err := cachePruneCmd.RunE(cachePruneCmd, nil); err != nil {
+		t.Fatalf("cache prune --all returned error: %v", err)
+	}
+	if entries, _ := cachedSkeletons(); len(entries) != 0 {
+		t.Errorf("Expected an empty cache, got %v", entries)
+	}
+}
+
+func TestSkeletonSource(t *testing.T) {
+	resetNewFlags()
+	t.Cleanup(resetNewFlags)
+
+	tests := []struct {
+		starterKit, version, pkg, want string
+		classComponents                bool
+	}{
+		{"", "", "laravel/laravel", "latest", false},
+		{"", "dev-master", "laravel/laravel", "dev-master", false},
+		{"laravel/livewire-starter-kit", "", "laravel/livewire-starter-kit", "latest", false},
+		{"laravel/livewire-starter-kit", "", "laravel/livewire-starter-kit", "dev-components", true},
+		{"acme/kit:^2.0", "", "acme/kit", "^2.0", false},
+	}
+	for _, tt := range tests {
+		livewireClassComponents = tt.classComponents
+		if pkg, version := skeletonSource(tt.starterKit, tt.version); pkg != tt.pkg || version != tt.want {
+			t.Errorf("skeletonSource(%q, %q) = %s %s, want %s %s", tt.starterKit, tt.version, pkg, version, tt.pkg, tt.want)
+		}
+	}
+}
+
+func TestExtractArchiveRefusesEscapes(t *testing.T) {
+	archive := func(header *tar.Header) *bytes.Buffer {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		tw.WriteHeader(header)
+		tw.Close()
+		gz.Close()
+		return &buf
+	}
+
+	for _, header := range []*tar.Header{
+		{Name: "../evil.php", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "vendor/bin/evil", Typeflag: tar.TypeSymlink, Linkname: "../../../etc/passwd"},
+		{Name: "vendor/bin/evil", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	} {
+		dir := filepath.Join(t.TempDir(), "project")
+		if err := extractArchive(archive(header), dir); err == nil || !strings.Contains(err.Error(), "refusing") {
+			t.Errorf("Expected %s -> %s to be refused, got %v", header.Name, header.Linkname, err)
+		}
+	}
+}
+
+func TestExtractArchiveRefusesSymlinkChains(t *testing.T) {
+	// z -> y/.. looks like it stays in the project, but y -> . so it is the
+	// parent of the project directory
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "y", Typeflag: tar.TypeSymlink, Linkname: "."})
+	tw.WriteHeader(&tar.Header{Name: "z", Typeflag: tar.TypeSymlink, Linkname: "y/.."})
+	tw.WriteHeader(&tar.Header{Name: "z/escaped.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 4})
+	tw.Write([]byte("evil"))
+	tw.Close()
+	gz.Close()
+
+	parent := t.TempDir()
+	err := extractArchive(&buf, filepath.Join(parent, "project"))
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("Expected the symlink chain to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(parent, "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be written outside the project directory")
+	}
+}
+
+// TestCacheWarmFromPathRepository warms the cache with the real composer from
+// a local path repository instead of Packagist.
+func TestCacheWarmFromPathRepository(t *testing.T) {
+	if _, err := exec.LookPath("composer"); err != nil {
+		t.Skip("composer is not installed")
+	}
+	resetNewFlags()
+	t.Cleanup(resetNewFlags)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("COMPOSER_HOME", t.TempDir())
+	quiet = true
+
+	repository := t.TempDir()
+	writeTestFile(filepath.Join(repository, "composer.json"), `{"name": "acme/skeleton", "type": "project", "version": "1.0.0", "require": {}}`)
+	writeTestFile(filepath.Join(repository, "artisan"), "<?php\n")
+	cacheRepository = repository
+	t.Cleanup(func() { cacheRepository = "" })
+
+	warmCache(t, "acme/skeleton")
+
+	project := filepath.Join(t.TempDir(), "demo")
+	f, err := os.Open(skeletonArchive("acme/skeleton", latestSkeleton))
+	if err != nil {
+		t.Fatalf("Expected the archive in the cache: %v", err)
+	}
+	defer f.Close()
+	if err := extractArchive(f, project); err != nil {
+		t.Fatalf("extractArchive returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(project, "artisan")); err != nil {
+		t.Errorf("Expected the package files in the archive: %v", err)
+	}
+}
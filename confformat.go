@@ -0,0 +1,517 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The config, preset and answers files accept a small, dependency free
+// subset of TOML and YAML: scalar values, lists of scalars and nested
+// tables/mappings. Nested keys are flattened into dotted names, so
+// `[drivers.mongodb]` + `port = 27017` and the equivalent YAML mapping both
+// produce the key "drivers.mongodb.port".
+
+// confEntry is a single setting read from a config file.
+type confEntry struct {
+	Key    string
+	Value  string
+	List   []string
+	IsList bool
+	Line   int
+}
+
+// confDoc is a parsed config file. Entries keep the order of the file.
+type confDoc struct {
+	Path    string
+	Entries []confEntry
+}
+
+// confError reports a problem at a specific line of a config file.
+type confError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e *confError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Path, e.Msg)
+}
+
+// lookup returns the last entry for key, so later definitions win.
+func (d *confDoc) lookup(key string) (confEntry, bool) {
+	for i := len(d.Entries) - 1; i >= 0; i-- {
+		if d.Entries[i].Key == key {
+			return d.Entries[i], true
+		}
+	}
+	return confEntry{}, false
+}
+
+// subKeys returns the distinct names directly below prefix, e.g. the preset
+// or driver names below "drivers".
+func (d *confDoc) subKeys(prefix string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, entry := range d.Entries {
+		if !strings.HasPrefix(entry.Key, prefix+".") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimPrefix(entry.Key, prefix+"."), ".", 2)[0]
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// firstWithPrefix returns the first entry whose key starts with prefix.
+func (d *confDoc) firstWithPrefix(prefix string) (confEntry, bool) {
+	for _, entry := range d.Entries {
+		if strings.HasPrefix(entry.Key, prefix) {
+			return entry, true
+		}
+	}
+	return confEntry{}, false
+}
+
+func isYAMLPath(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// readConfigFile parses a TOML or YAML file depending on its extension.
+func readConfigFile(path string) (*confDoc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(path, data)
+}
+
+func parseConfig(path string, data []byte) (*confDoc, error) {
+	var entries []confEntry
+	var err error
+	if isYAMLPath(path) {
+		entries, err = parseYAML(data)
+	} else {
+		entries, err = parseTOML(data)
+	}
+	if err != nil {
+		if ce, ok := err.(*confError); ok {
+			ce.Path = path
+		}
+		return nil, err
+	}
+	return &confDoc{Path: path, Entries: entries}, nil
+}
+
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func parseTOML(data []byte) ([]confEntry, error) {
+	var entries []confEntry
+	table := ""
+	lines := splitLines(string(data))
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, &confError{Line: lineNo, Msg: "invalid table header " + line}
+			}
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			for _, part := range strings.Split(name, ".") {
+				if !bareKeyPattern.MatchString(strings.TrimSpace(part)) {
+					return nil, &confError{Line: lineNo, Msg: "invalid table name " + name}
+				}
+			}
+			table = name
+			continue
+		}
+
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, &confError{Line: lineNo, Msg: "expected key = value"}
+		}
+		key, err := parseKey(strings.TrimSpace(line[:eq]))
+		if err != nil {
+			return nil, &confError{Line: lineNo, Msg: err.Error()}
+		}
+		raw := strings.TrimSpace(line[eq+1:])
+
+		// Arrays may span several lines
+		if strings.HasPrefix(raw, "[") {
+			for !strings.HasSuffix(raw, "]") && i+1 < len(lines) {
+				i++
+				raw += " " + strings.TrimSpace(stripComment(lines[i]))
+			}
+		}
+
+		entry := confEntry{Key: joinKey(table, key), Line: lineNo}
+		if err := parseValue(raw, &entry, false); err != nil {
+			return nil, &confError{Line: lineNo, Msg: err.Error()}
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+type yamlFrame struct {
+	indent int
+	key    string
+}
+
+func parseYAML(data []byte) ([]confEntry, error) {
+	var entries []confEntry
+	stack := []yamlFrame{{indent: -1}}
+	pending := map[string]int{} // keys declared with no inline value, by entry index
+
+	for i, rawLine := range splitLines(string(data)) {
+		lineNo := i + 1
+		if strings.HasPrefix(strings.TrimSpace(rawLine), "#") || strings.TrimSpace(rawLine) == "" {
+			continue
+		}
+		if strings.TrimSpace(rawLine) == "---" {
+			continue
+		}
+		if strings.HasPrefix(strings.TrimLeft(rawLine, " "), "\t") {
+			return nil, &confError{Line: lineNo, Msg: "tabs are not allowed for indentation"}
+		}
+
+		indent := len(rawLine) - len(strings.TrimLeft(rawLine, " "))
+		content := strings.TrimSpace(stripComment(rawLine))
+		isItem := content == "-" || strings.HasPrefix(content, "- ")
+
+		for len(stack) > 1 {
+			top := stack[len(stack)-1]
+			if top.indent < indent || (top.indent == indent && isItem) {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1]
+
+		if isItem {
+			index, ok := pending[parent.key]
+			if !ok || parent.key == "" {
+				return nil, &confError{Line: lineNo, Msg: "list item without a key"}
+			}
+			value := strings.TrimSpace(strings.TrimPrefix(content, "-"))
+			if strings.Contains(value, ": ") || strings.HasSuffix(value, ":") {
+				return nil, &confError{Line: lineNo, Msg: "nested mappings in lists are not supported"}
+			}
+			item, err := unquote(value)
+			if err != nil {
+				return nil, &confError{Line: lineNo, Msg: err.Error()}
+			}
+			entries[index].IsList = true
+			entries[index].List = append(entries[index].List, item)
+			continue
+		}
+
+		colon := yamlKeySeparator(content)
+		if colon < 0 {
+			return nil, &confError{Line: lineNo, Msg: "expected key: value"}
+		}
+		key, err := parseKey(strings.TrimSpace(content[:colon]))
+		if err != nil {
+			return nil, &confError{Line: lineNo, Msg: err.Error()}
+		}
+		if index, ok := pending[parent.key]; ok && entries[index].IsList {
+			return nil, &confError{Line: lineNo, Msg: "cannot mix list items and keys under " + parent.key}
+		}
+
+		fullKey := joinKey(parent.key, key)
+		raw := strings.TrimSpace(content[colon+1:])
+		if raw == "" {
+			// Either a nested mapping, a block list or an empty value
+			entries = append(entries, confEntry{Key: fullKey, Line: lineNo})
+			pending[fullKey] = len(entries) - 1
+			stack = append(stack, yamlFrame{indent: indent, key: fullKey})
+			continue
+		}
+
+		entry := confEntry{Key: fullKey, Line: lineNo}
+		if err := parseValue(raw, &entry, true); err != nil {
+			return nil, &confError{Line: lineNo, Msg: err.Error()}
+		}
+		entries = append(entries, entry)
+	}
+
+	// Drop the placeholder entries of keys that turned out to be mappings
+	var result []confEntry
+	for _, entry := range entries {
+		if _, ok := pending[entry.Key]; ok && !entry.IsList && hasChildren(entries, entry.Key) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func hasChildren(entries []confEntry, key string) bool {
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Key, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlKeySeparator finds the colon that ends a mapping key, skipping quoted keys.
+func yamlKeySeparator(content string) int {
+	start := 0
+	if strings.HasPrefix(content, `"`) || strings.HasPrefix(content, "'") {
+		end := strings.Index(content[1:], content[:1])
+		if end < 0 {
+			return -1
+		}
+		start = end + 2
+	}
+	for i := start; i < len(content); i++ {
+		if content[i] == ':' && (i == len(content)-1 || content[i+1] == ' ') {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseKey(key string) (string, error) {
+	if strings.HasPrefix(key, `"`) || strings.HasPrefix(key, "'") {
+		return unquote(key)
+	}
+	if !bareKeyPattern.MatchString(key) {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return key, nil
+}
+
+// parseValue parses a scalar or a flow list. YAML allows unquoted strings,
+// TOML requires quotes around anything that is not a bool or number.
+func parseValue(raw string, entry *confEntry, yaml bool) error {
+	if strings.HasPrefix(raw, "[") {
+		if !strings.HasSuffix(raw, "]") {
+			return fmt.Errorf("unterminated list")
+		}
+		entry.IsList = true
+		entry.List = []string{}
+		for _, item := range splitList(raw[1 : len(raw)-1]) {
+			value, err := parseScalar(item, yaml)
+			if err != nil {
+				return err
+			}
+			entry.List = append(entry.List, value)
+		}
+		return nil
+	}
+	if strings.HasPrefix(raw, "{") {
+		return fmt.Errorf("inline tables are not supported")
+	}
+
+	value, err := parseScalar(raw, yaml)
+	entry.Value = value
+	return err
+}
+
+var numberPattern = regexp.MustCompile(`^[+-]?[0-9][0-9_]*(\.[0-9]+)?$`)
+
+func parseScalar(raw string, yaml bool) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'") {
+		return unquote(raw)
+	}
+	if yaml {
+		if raw == "~" || raw == "null" {
+			return "", nil
+		}
+		return raw, nil
+	}
+	if raw == "true" || raw == "false" || numberPattern.MatchString(raw) {
+		return strings.ReplaceAll(raw, "_", ""), nil
+	}
+	if raw == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	return "", fmt.Errorf("strings must be quoted: %s", raw)
+}
+
+func unquote(raw string) (string, error) {
+	if len(raw) < 2 || raw[len(raw)-1] != raw[0] || (raw[0] != '"' && raw[0] != '\'') {
+		if raw != "" && (raw[0] == '"' || raw[0] == '\'') {
+			return "", fmt.Errorf("unterminated string %s", raw)
+		}
+		return raw, nil
+	}
+	if raw[0] == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	value, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", raw)
+	}
+	return value, nil
+}
+
+// splitList splits a flow list body on commas outside quotes.
+func splitList(body string) []string {
+	var items []string
+	var current strings.Builder
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(body) {
+				current.WriteByte(c)
+				i++
+				c = body[i]
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			if item := strings.TrimSpace(current.String()); item != "" {
+				items = append(items, item)
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	if item := strings.TrimSpace(current.String()); item != "" {
+		items = append(items, item)
+	}
+	return items
+}
+
+// stripComment removes a trailing # comment that is not inside quotes.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func splitLines(data string) []string {
+	return strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n")
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// formatConfigValue renders a scalar for the given file format.
+func formatConfigValue(path, value string) string {
+	if value == "true" || value == "false" || numberPattern.MatchString(value) {
+		return value
+	}
+	if isYAMLPath(path) && value != "" && !strings.ContainsAny(value, ":#'\"[]{},&*!|>%@`") &&
+		strings.TrimSpace(value) == value {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+// formatConfigList renders a list of strings as a flow list, which both
+// formats accept.
+func formatConfigList(values []string) string {
+	items := make([]string, len(values))
+	for i, value := range values {
+		items[i] = strconv.Quote(value)
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// setTopLevelKey updates or inserts a top-level `key = value` (TOML) or
+// `key: value` (YAML) line, keeping comments and the rest of the file intact.
+// An empty rendered value removes the key instead.
+func setTopLevelKey(path string, data []byte, key, rendered string, remove bool) []byte {
+	lines := splitLines(string(data))
+	yaml := isYAMLPath(path)
+	line := key + " = " + rendered
+	if yaml {
+		line = key + ": " + rendered
+	}
+
+	insertAt := len(lines)
+	for len(lines) > 0 && insertAt == len(lines) && lines[len(lines)-1] == "" {
+		insertAt--
+	}
+	for i, current := range lines {
+		trimmed := strings.TrimSpace(current)
+		if !yaml && strings.HasPrefix(trimmed, "[") {
+			// Top-level keys must come before the first table
+			insertAt = i
+			for insertAt > 0 && strings.TrimSpace(lines[insertAt-1]) == "" {
+				insertAt--
+			}
+			break
+		}
+		if yaml && current != strings.TrimLeft(current, " ") {
+			continue
+		}
+		if topLevelKeyOf(trimmed, yaml) == key {
+			if remove {
+				return []byte(strings.Join(append(lines[:i:i], lines[i+1:]...), "\n"))
+			}
+			lines[i] = line
+			return []byte(strings.Join(lines, "\n"))
+		}
+	}
+
+	if remove {
+		return data
+	}
+	lines = append(lines[:insertAt], append([]string{line}, lines[insertAt:]...)...)
+	result := strings.Join(lines, "\n")
+	if !strings.HasSuffix(result, "\n") {
+		result += "\n"
+	}
+	return []byte(result)
+}
+
+func topLevelKeyOf(line string, yaml bool) string {
+	sep := "="
+	if yaml {
+		sep = ":"
+	}
+	index := strings.Index(line, sep)
+	if index < 0 || strings.HasPrefix(line, "#") {
+		return ""
+	}
+	key, err := parseKey(strings.TrimSpace(line[:index]))
+	if err != nil {
+		return ""
+	}
+	return key
+}
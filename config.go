@@ -0,0 +1,448 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Defaults for `laravel new` are read from, in increasing order of priority:
+//
+//  1. the user config file (~/.config/laravel/config.toml or config.yaml)
+//  2. the project config file (.laravel/config.toml in the current directory
+//     or one of its parents)
+//  3. LARAVEL_* environment variables, e.g. LARAVEL_DATABASE=pgsql
+//  4. flags given on the command line
+//
+// Config keys are the long flag names of `laravel new`.
+
+// configFileNames are tried in order inside a config directory.
+var configFileNames = []string{"config.toml", "config.yaml", "config.yml"}
+
+// configExcludedFlags cannot be given a default because they are destructive
+// or only make sense for a single run.
+var configExcludedFlags = map[string]bool{"force": true, "dry-run": true, "help": true, "db-password": true, "database-url": true}
+
+// configEnvExcludedFlags are only read from config files, because their
+// variable means something else: Sail sets LARAVEL_SAIL=1 in its containers.
+var configEnvExcludedFlags = map[string]bool{"sail": true}
+
+// configValidators check values beyond what the flag type enforces.
+var configValidators = map[string]func(string) error{
+	"database": func(value string) error {
+		if value == "" {
+			return nil
+		}
+		return validateDriverName(value)
+	},
+	"format": func(value string) error {
+		if value != "text" && value != "json" {
+			return fmt.Errorf("possible values are: text, json")
+		}
+		return nil
+	},
+	"cache":     allowEmpty(validateServiceDriver("cache")),
+	"queue":     allowEmpty(validateServiceDriver("queue")),
+	"session":   allowEmpty(validateServiceDriver("session")),
+	"mail":      allowEmpty(validateServiceDriver("mail")),
+	"broadcast": allowEmpty(validateServiceDriver("broadcast")),
+	"jobs": func(value string) error {
+		if n, _ := strconv.Atoi(value); n < 1 {
+			return fmt.Errorf("at least one step has to run at a time")
+		}
+		return nil
+	},
+}
+
+// allowEmpty lets an empty value reset an option to its default.
+func allowEmpty(validate func(string) error) func(string) error {
+	return func(value string) error {
+		if value == "" {
+			return nil
+		}
+		return validate(value)
+	}
+}
+
+// configValue is the effective value of a config key and where it came from.
+type configValue struct {
+	Key    string
+	Value  string
+	Source string // File path, environment variable or "default"
+	Line   int
+}
+
+func (v configValue) location() string {
+	if v.Line > 0 {
+		return fmt.Sprintf("%s:%d", v.Source, v.Line)
+	}
+	return v.Source
+}
+
+// userConfigDir returns the directory holding the user config file. It
+// honours XDG_CONFIG_HOME and falls back to ~/.config/laravel, also on macOS.
+func userConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "laravel")
+	}
+	if runtime.GOOS == "windows" {
+		if dir, err := os.UserConfigDir(); err == nil {
+			return filepath.Join(dir, "laravel")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "laravel")
+}
+
+// findConfigFile returns the first config file that exists in dir.
+func findConfigFile(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// userConfigPath returns the user config file, or where it would be created.
+func userConfigPath() string {
+	if path := findConfigFile(userConfigDir()); path != "" {
+		return path
+	}
+	return filepath.Join(userConfigDir(), configFileNames[0])
+}
+
+// projectConfigDir looks for a .laravel directory with a config file in the
+// current directory and its parents.
+func projectConfigDir() string {
+	return findInParents(".laravel", func(path string) bool {
+		return findConfigFile(path) != ""
+	})
+}
+
+// findInParents returns the first dir/name, walking up from the current
+// directory, for which match returns true.
+func findInParents(name string, match func(path string) bool) string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for {
+		if candidate := filepath.Join(dir, name); match(candidate) {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// projectConfigPath returns the project config file, or where it would be
+// created in the current directory.
+func projectConfigPath() string {
+	if path := findConfigFile(projectConfigDir()); path != "" {
+		return path
+	}
+	cwd, _ := os.Getwd()
+	return filepath.Join(cwd, ".laravel", configFileNames[0])
+}
+
+// loadConfigFiles reads the user and project config files that exist, in
+// order of increasing priority.
+func loadConfigFiles() ([]*confDoc, error) {
+	var docs []*confDoc
+	for _, path := range []string{findConfigFile(userConfigDir()), findConfigFile(projectConfigDir())} {
+		if path == "" {
+			continue
+		}
+		doc, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// configEnvName maps a config key to its environment variable.
+func configEnvName(key string) string {
+	return "LARAVEL_" + strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+func isConfigurable(flag *pflag.Flag) bool {
+	return flag != nil && !configExcludedFlags[flag.Name]
+}
+
+// configurableKeys lists every key that can be set, sorted by name.
+func configurableKeys(flags *pflag.FlagSet) []string {
+	var keys []string
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if isConfigurable(flag) {
+			keys = append(keys, flag.Name)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// validateConfigValue checks that value is acceptable for the flag.
+func validateConfigValue(flag *pflag.Flag, value string) error {
+	if flag.Value.Type() == "bool" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+	}
+	if flag.Value.Type() == "int" {
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("expected a whole number")
+		}
+	}
+	if validate, ok := configValidators[flag.Name]; ok {
+		return validate(value)
+	}
+	return nil
+}
+
+// resolveConfig merges the config files and environment into the effective
+// value of every key that has been set somewhere.
+func resolveConfig(flags *pflag.FlagSet) (map[string]configValue, error) {
+	docs, err := loadConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	// Driver tables extend the values the database option accepts
+	if err := registerConfigDrivers(docs); err != nil {
+		return nil, err
+	}
+
+	values := map[string]configValue{}
+	for _, doc := range docs {
+		for _, entry := range doc.Entries {
+			if strings.HasPrefix(entry.Key, "drivers.") {
+				continue
+			}
+			flag := flags.Lookup(entry.Key)
+			if !isConfigurable(flag) {
+				return nil, &confError{Path: doc.Path, Line: entry.Line, Msg: fmt.Sprintf("unknown option %q", entry.Key)}
+			}
+			if entry.IsList {
+				return nil, &confError{Path: doc.Path, Line: entry.Line, Msg: fmt.Sprintf("%s expects a single value", entry.Key)}
+			}
+			values[entry.Key] = configValue{Key: entry.Key, Value: entry.Value, Source: doc.Path, Line: entry.Line}
+		}
+	}
+
+	for _, key := range configurableKeys(flags) {
+		if configEnvExcludedFlags[key] {
+			continue
+		}
+		name := configEnvName(key)
+		if value, ok := os.LookupEnv(name); ok {
+			values[key] = configValue{Key: key, Value: value, Source: name}
+		}
+	}
+
+	for _, value := range values {
+		if err := validateConfigValue(flags.Lookup(value.Key), value.Value); err != nil {
+			return nil, fmt.Errorf("%s: invalid value %q for %s: %v", value.location(), value.Value, value.Key, err)
+		}
+	}
+	return values, nil
+}
+
+// applyConfigDefaults fills in every flag that was not given on the command
+// line from the config files and environment.
+func applyConfigDefaults(flags *pflag.FlagSet) error {
+	values, err := resolveConfig(flags)
+	if err != nil {
+		return newExitError(exitUsage, err)
+	}
+
+	for _, value := range values {
+		flag := flags.Lookup(value.Key)
+		if flag.Changed {
+			continue
+		}
+		if err := flag.Value.Set(value.Value); err != nil {
+			return exitErrorf(exitUsage, "%s: invalid value %q for %s: %v", value.location(), value.Value, value.Key, err)
+		}
+	}
+	return nil
+}
+
+// writeConfigValue sets or removes key in the config file at path, creating
+// the file when needed.
+func writeConfigValue(path, key, value string, remove bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if os.IsNotExist(err) && remove {
+		return nil
+	}
+
+	// Reject files we could not read back
+	if _, err := parseConfig(path, data); err != nil {
+		return err
+	}
+
+	updated := setTopLevelKey(path, data, key, formatConfigValue(path, value), remove)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, updated, 0644)
+}
+
+var configProject bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the default options for new applications",
+	Long: "Manage the default options for `laravel new`.\n\n" +
+		"Defaults are read from the user config file, then the project config file (.laravel/config.toml),\n" +
+		"then LARAVEL_* environment variables. Flags given on the command line always win.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:          "get <key>",
+	Short:        "Print the effective value of an option",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flag := newCmd.Flags().Lookup(args[0])
+		if !isConfigurable(flag) {
+			return exitErrorf(exitUsage, "Unknown option %q. Run `laravel config list` to see all options", args[0])
+		}
+		values, err := resolveConfig(newCmd.Flags())
+		if err != nil {
+			return err
+		}
+		if value, ok := values[flag.Name]; ok {
+			fmt.Fprintln(stdout, value.Value)
+		} else {
+			fmt.Fprintln(stdout, flag.DefValue)
+		}
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:          "set <key> <value>",
+	Short:        "Set the default value of an option",
+	Args:         usageArgs(cobra.ExactArgs(2)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		flag := newCmd.Flags().Lookup(key)
+		if !isConfigurable(flag) {
+			return exitErrorf(exitUsage, "Unknown option %q. Run `laravel config list` to see all options", key)
+		}
+		if err := validateConfigValue(flag, value); err != nil {
+			return exitErrorf(exitUsage, "Invalid value %q for %s: %v", value, key, err)
+		}
+
+		path := configTargetPath()
+		if err := writeConfigValue(path, key, value, false); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Set %s = %s in %s\n", key, value, path)
+		return nil
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:          "unset <key>",
+	Short:        "Remove the default value of an option",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !isConfigurable(newCmd.Flags().Lookup(args[0])) {
+			return exitErrorf(exitUsage, "Unknown option %q. Run `laravel config list` to see all options", args[0])
+		}
+		path := configTargetPath()
+		if err := writeConfigValue(path, args[0], "", true); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Removed %s from %s\n", args[0], path)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List every option with its effective value and source",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		values, err := resolveConfig(newCmd.Flags())
+		if err != nil {
+			return err
+		}
+		printConfigList(values)
+		return nil
+	},
+}
+
+var configPathCmd = &cobra.Command{
+	Use:          "path",
+	Short:        "Print the path of the config file",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		fmt.Fprintln(stdout, configTargetPath())
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{configSetCmd, configUnsetCmd, configPathCmd} {
+		cmd.Flags().BoolVar(&configProject, "project", false, "Use the project config file (.laravel/config.toml) instead of the user config file")
+	}
+	configCmd.AddCommand(configGetCmd, configSetCmd, configUnsetCmd, configListCmd, configPathCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func configTargetPath() string {
+	if configProject {
+		return projectConfigPath()
+	}
+	return userConfigPath()
+}
+
+func printConfigList(values map[string]configValue) {
+	keys := configurableKeys(newCmd.Flags())
+	width := 0
+	for _, key := range keys {
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+
+	for _, key := range keys {
+		value, ok := values[key]
+		if !ok {
+			value = configValue{Value: newCmd.Flags().Lookup(key).DefValue, Source: "default"}
+		}
+		fmt.Fprintf(stdout, "%-*s  %-12s  (%s)\n", width, key, strconv.Quote(value.Value), value.location())
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestParseTOML(t *testing.T) {
+	data := `# Team defaults
+database = "pgsql"
+pest = true
+branch = 'main' # inline comment
+jobs = 4
+
+[drivers.mongodb]
+port = 27017
+packages = [
+  "mongodb/laravel-mongodb",
+  "acme/extra", # trailing comma
+]
+`
+	doc, err := parseConfig("config.toml", []byte(data))
+	if err != nil {
+		t.Fatalf("parseConfig returned error: %v", err)
+	}
+
+	for key, want := range map[string]string{"database": "pgsql", "pest": "true", "branch": "main", "jobs": "4", "drivers.mongodb.port": "27017"} {
+		if entry, ok := doc.lookup(key); !ok || entry.Value != want {
+			t.Errorf("Expected %s = %q, got %q", key, want, entry.Value)
+		}
+	}
+	packages, _ := doc.lookup("drivers.mongodb.packages")
+	if !packages.IsList || strings.Join(packages.List, ",") != "mongodb/laravel-mongodb,acme/extra" {
+		t.Errorf("Unexpected packages list: %v", packages.List)
+	}
+	if entry, _ := doc.lookup("database"); entry.Line != 2 {
+		t.Errorf("Expected database on line 2, got %d", entry.Line)
+	}
+	if names := doc.subKeys("drivers"); len(names) != 1 || names[0] != "mongodb" {
+		t.Errorf("Unexpected driver names: %v", names)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data := `---
+database: pgsql # comment
+organization: "acme"
+git: true
+drivers:
+  mongodb:
+    port: 27017
+    packages:
+      - mongodb/laravel-mongodb
+      - 'acme/extra'
+    extensions: [mongodb]
+empty:
+`
+	doc, err := parseConfig("config.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("parseConfig returned error: %v", err)
+	}
+
+	for key, want := range map[string]string{"database": "pgsql", "organization": "acme", "git": "true", "drivers.mongodb.port": "27017", "empty": ""} {
+		if entry, ok := doc.lookup(key); !ok || entry.Value != want {
+			t.Errorf("Expected %s = %q, got %q (found %v)", key, want, entry.Value, ok)
+		}
+	}
+	packages, _ := doc.lookup("drivers.mongodb.packages")
+	if strings.Join(packages.List, ",") != "mongodb/laravel-mongodb,acme/extra" {
+		t.Errorf("Unexpected packages list: %v", packages.List)
+	}
+	extensions, _ := doc.lookup("drivers.mongodb.extensions")
+	if !extensions.IsList || len(extensions.List) != 1 || extensions.List[0] != "mongodb" {
+		t.Errorf("Unexpected extensions list: %v", extensions.List)
+	}
+	if _, ok := doc.lookup("drivers"); ok {
+		t.Error("Expected mapping keys not to be entries themselves")
+	}
+}
+
+func TestParseConfigErrorsIncludeLine(t *testing.T) {
+	testCases := []struct {
+		path string
+		data string
+		want string
+	}{
+		{"config.toml", "database = \"pgsql\"\nbranch = main\n", "config.toml:2: strings must be quoted"},
+		{"config.toml", "pest = true\n[new\n", "config.toml:2: invalid table header"},
+		{"config.toml", "\n\nkey\n", "config.toml:3: expected key = value"},
+		{"config.yaml", "database: pgsql\n\tgit: true\n", "config.yaml:2: tabs are not allowed"},
+		{"config.yaml", "- item\n", "config.yaml:1: list item without a key"},
+		{"config.yml", "a: \"open\n", "config.yml:1: unterminated string"},
+	}
+
+	for _, tc := range testCases {
+		_, err := parseConfig(tc.path, []byte(tc.data))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("parseConfig(%q) error = %v; want %q", tc.data, err, tc.want)
+		}
+	}
+}
+
+// testNewFlags builds a flag set with a few of the `laravel new` flags bound
+// to local variables.
+func testNewFlags() (*pflag.FlagSet, map[string]*string, *bool) {
+	flags := pflag.NewFlagSet("new", pflag.ContinueOnError)
+	values := map[string]*string{
+		"database":     flags.String("database", "", ""),
+		"branch":       flags.String("branch", "", ""),
+		"organization": flags.String("organization", "", ""),
+	}
+	pest := flags.Bool("pest", false, "")
+	flags.Bool("force", false, "")
+	return flags, values, pest
+}
+
+// useConfigDirs points the user config at a temporary directory and runs the
+// test from a temporary project directory.
+func useConfigDirs(t *testing.T) (userDir, projectDir string) {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", home)
+	for _, key := range []string{"database", "branch", "organization", "pest", "force"} {
+		t.Setenv(configEnvName(key), "")
+		os.Unsetenv(configEnvName(key))
+	}
+
+	projectDir = t.TempDir()
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(projectDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(oldDir) })
+
+	userDir = filepath.Join(home, "laravel")
+	os.MkdirAll(userDir, 0755)
+	os.MkdirAll(filepath.Join(projectDir, ".laravel"), 0755)
+	return userDir, projectDir
+}
+
+func TestApplyConfigDefaultsPrecedence(t *testing.T) {
+	userDir, projectDir := useConfigDirs(t)
+	writeTestFile(filepath.Join(userDir, "config.toml"), "database = \"mysql\"\nbranch = \"main\"\norganization = \"acme\"\npest = true\n")
+	writeTestFile(filepath.Join(projectDir, ".laravel", "config.yaml"), "database: pgsql\nbranch: trunk\n")
+	t.Setenv("LARAVEL_BRANCH", "develop")
+
+	flags, values, pest := testNewFlags()
+	if err := flags.Parse([]string{"--pest=false"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+	if err := applyConfigDefaults(flags); err != nil {
+		t.Fatalf("applyConfigDefaults returned error: %v", err)
+	}
+
+	if *values["organization"] != "acme" {
+		t.Errorf("Expected organization from the user config, got %q", *values["organization"])
+	}
+	if *values["database"] != "pgsql" {
+		t.Errorf("Expected the project config to override the user config, got %q", *values["database"])
+	}
+	if *values["branch"] != "develop" {
+		t.Errorf("Expected LARAVEL_BRANCH to override the config files, got %q", *values["branch"])
+	}
+	if *pest {
+		t.Error("Expected the --pest=false flag to override the config files")
+	}
+}
+
+func TestSailIsNotReadFromTheEnvironment(t *testing.T) {
+	userDir, _ := useConfigDirs(t)
+	t.Setenv("LARAVEL_SAIL", "1") // Set by Sail inside the app container
+
+	flags, _, _ := testNewFlags()
+	sail := flags.Bool("sail", false, "")
+	if err := applyConfigDefaults(flags); err != nil {
+		t.Fatalf("applyConfigDefaults returned error: %v", err)
+	}
+	if *sail {
+		t.Error("Expected LARAVEL_SAIL to be ignored")
+	}
+
+	writeTestFile(filepath.Join(userDir, "config.toml"), "sail = true\n")
+	if err := applyConfigDefaults(flags); err != nil {
+		t.Fatalf("applyConfigDefaults returned error: %v", err)
+	}
+	if !*sail {
+		t.Error("Expected sail to be read from the config file")
+	}
+}
+
+func TestApplyConfigDefaultsErrors(t *testing.T) {
+	t.Run("UnknownKey", func(t *testing.T) {
+		userDir, _ := useConfigDirs(t)
+		writeTestFile(filepath.Join(userDir, "config.toml"), "pest = true\ndatabse = \"pgsql\"\n")
+
+		flags, _, _ := testNewFlags()
+		err := applyConfigDefaults(flags)
+		if exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), "config.toml:2: unknown option \"databse\"") {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("ExcludedKey", func(t *testing.T) {
+		userDir, _ := useConfigDirs(t)
+		writeTestFile(filepath.Join(userDir, "config.toml"), "force = true\n")
+
+		flags, _, _ := testNewFlags()
+		if err := applyConfigDefaults(flags); err == nil {
+			t.Error("Expected force to be rejected as a default")
+		}
+	})
+
+	t.Run("InvalidValue", func(t *testing.T) {
+		useConfigDirs(t)
+		t.Setenv("LARAVEL_DATABASE", "oracle")
+
+		flags, _, _ := testNewFlags()
+		err := applyConfigDefaults(flags)
+		if err == nil || !strings.Contains(err.Error(), "LARAVEL_DATABASE") {
+			t.Errorf("Expected an error naming LARAVEL_DATABASE, got %v", err)
+		}
+	})
+}
+
+func TestWriteConfigValuePreservesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeTestFile(path, "# Team defaults\ndatabase = \"mysql\" # for now\n\n[drivers.mongodb]\nport = 27017\n")
+
+	if err := writeConfigValue(path, "database", "pgsql", false); err != nil {
+		t.Fatalf("writeConfigValue returned error: %v", err)
+	}
+	if err := writeConfigValue(path, "branch", "main", false); err != nil {
+		t.Fatalf("writeConfigValue returned error: %v", err)
+	}
+	if err := writeConfigValue(path, "pest", "true", false); err != nil {
+		t.Fatalf("writeConfigValue returned error: %v", err)
+	}
+
+	content, _ := readTestFile(path)
+	want := "# Team defaults\ndatabase = \"pgsql\"\nbranch = \"main\"\npest = true\n\n[drivers.mongodb]\nport = 27017\n"
+	if content != want {
+		t.Errorf("Unexpected config file:\n%s\nwant:\n%s", content, want)
+	}
+
+	if err := writeConfigValue(path, "branch", "", true); err != nil {
+		t.Fatalf("writeConfigValue returned error: %v", err)
+	}
+	doc, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the config back: %v", err)
+	}
+	if _, ok := doc.lookup("branch"); ok {
+		t.Error("Expected branch to be removed")
+	}
+	if entry, _ := doc.lookup("drivers.mongodb.port"); entry.Value != "27017" {
+		t.Error("Expected tables to be left alone")
+	}
+}
+
+func TestWriteConfigValueYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".laravel", "config.yaml")
+
+	if err := writeConfigValue(path, "organization", "acme", false); err != nil {
+		t.Fatalf("writeConfigValue returned error: %v", err)
+	}
+	if err := writeConfigValue(path, "using", "acme/kit:dev-main", false); err != nil {
+		t.Fatalf("writeConfigValue returned error: %v", err)
+	}
+
+	doc, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read the config back: %v", err)
+	}
+	if entry, _ := doc.lookup("using"); entry.Value != "acme/kit:dev-main" {
+		t.Errorf("Expected the quoted value to round-trip, got %q", entry.Value)
+	}
+	if entry, _ := doc.lookup("organization"); entry.Value != "acme" {
+		t.Errorf("Expected organization = acme, got %q", entry.Value)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// databaseDialTimeout bounds the connectivity check before migrations.
+var databaseDialTimeout = 3 * time.Second
+
+// mysqlDefaultSockets are where MySQL and MariaDB put their socket on common
+// systems. PDO connects to "localhost" through the socket instead of TCP.
+var mysqlDefaultSockets = []string{"/var/run/mysqld/mysqld.sock", "/tmp/mysql.sock", "/var/lib/mysql/mysql.sock"}
+
+// databaseEndpoint is where the database server of a project listens.
+type databaseEndpoint struct {
+	Network string // "tcp" or "unix"
+	Address string
+}
+
+// resolveDatabaseEndpoint reads the connection settings of a server-based
+// driver from an environment file, applying Laravel's defaults.
+func resolveDatabaseEndpoint(envPath string, driver *databaseDriver) (databaseEndpoint, error) {
+	settings, err := loadDatabaseSettings(envPath, driver.Keys)
+	if err != nil {
+		return databaseEndpoint{}, err
+	}
+	// SRV records name the hosts; leave resolving them to the driver
+	if strings.HasSuffix(strings.SplitN(settings.URL, ":", 2)[0], "+srv") {
+		return databaseEndpoint{}, fmt.Errorf("%s uses DNS seed list discovery", driver.Keys.URL)
+	}
+	host := settings.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	port := driver.Port
+	if settings.Port != "" {
+		if port, err = strconv.Atoi(settings.Port); err != nil || port < 1 || port > 65535 {
+			return databaseEndpoint{}, fmt.Errorf("invalid port %q", settings.Port)
+		}
+	}
+
+	switch driver.Protocol {
+	case "mysql":
+		if settings.Socket != "" {
+			return databaseEndpoint{Network: "unix", Address: settings.Socket}, nil
+		}
+		if host == "localhost" {
+			for _, socket := range mysqlDefaultSockets {
+				if _, err := os.Stat(socket); err == nil {
+					return databaseEndpoint{Network: "unix", Address: socket}, nil
+				}
+			}
+		}
+	case "pgsql":
+		// libpq treats a host starting with a slash as the socket directory
+		if strings.HasPrefix(host, "/") {
+			return databaseEndpoint{Network: "unix", Address: filepath.Join(host, fmt.Sprintf(".s.PGSQL.%d", port))}, nil
+		}
+	}
+	return databaseEndpoint{Network: "tcp", Address: net.JoinHostPort(host, strconv.Itoa(port))}, nil
+}
+
+// dialDatabase checks that something accepts connections at the endpoint.
+func dialDatabase(ctx context.Context, endpoint databaseEndpoint) error {
+	dialer := net.Dialer{Timeout: databaseDialTimeout}
+	conn, err := dialer.DialContext(ctx, endpoint.Network, endpoint.Address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// diagnoseDatabase explains a failed connectivity check.
+func diagnoseDatabase(driver *databaseDriver, endpoint databaseEndpoint, err error) string {
+	name := driver.DisplayName
+
+	var reason string
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		reason = fmt.Sprintf("the host %q could not be resolved", dnsErr.Name)
+	case endpoint.Network == "unix" && errors.Is(err, os.ErrNotExist):
+		reason = fmt.Sprintf("the socket %s does not exist", endpoint.Address)
+	case errors.Is(err, os.ErrPermission):
+		reason = fmt.Sprintf("permission to connect to %s was denied", endpoint.Address)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		reason = fmt.Sprintf("nothing is listening on %s", endpoint.Address)
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		reason = fmt.Sprintf("connecting to %s timed out after %s", endpoint.Address, databaseDialTimeout)
+	default:
+		reason = err.Error()
+	}
+
+	message := fmt.Sprintf("%s is not reachable: %s", name, reason)
+	if driver.StartHint != "" {
+		message += ". " + driver.StartHint
+	}
+	if driver.Keys == sqlEnvKeys {
+		return message + ", or correct the DB_* settings in .env"
+	}
+	return message + ", or correct " + driver.Keys.URL + " in .env"
+}
+
+// checkDatabaseBeforeMigrating reports whether the migrations should run. When
+// the server is unreachable the user may skip them instead of getting a PHP
+// stack trace; without interaction they are skipped.
+func checkDatabaseBeforeMigrating(ctx context.Context, p *prompter, projectDir, driverName string) (bool, error) {
+	driver := lookupDriver(driverName)
+	if driver == nil || !driver.hasServer() {
+		return true, nil
+	}
+	endpoint, err := resolveDatabaseEndpoint(filepath.Join(projectDir, ".env"), driver)
+	if err != nil {
+		// Leave reporting broken settings to artisan
+		return true, nil
+	}
+
+	err = dialDatabase(ctx, endpoint)
+	if err == nil || isInterrupted(ctx) {
+		return true, nil
+	}
+
+	diagnosis := diagnoseDatabase(driver, endpoint, err)
+	printWarning(ctx, diagnosis)
+	if askForConfirmation(p, "migrate_unreachable", "Run the migrations anyway?") {
+		return true, nil
+	}
+	if strict {
+		return false, exitErrorf(exitMigrationFailed, "%s", diagnosis)
+	}
+	if !quiet {
+		fmt.Println("Skipped the database migrations. Run `php artisan migrate` once the database is reachable.")
+	}
+	return false, nil
+}
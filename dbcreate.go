@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// databaseScript creates or drops the comma separated databases named in
+// LARAVEL_DB_NAMES through PDO, so it works wherever the application itself can connect. It
+// prints "created <name>", "exists <name>" or "dropped <name>" per database
+// and exits with one of the databaseScript* codes on failure.
+const databaseScript = `$env = fn ($key) => (string) getenv("LARAVEL_DB_$key");
+[$dsn, $open, $close, $exists] = match ($env('DRIVER')) {
+    'mysql', 'mariadb' => [$env('SOCKET') !== '' ? 'mysql:unix_socket='.$env('SOCKET') : 'mysql:host='.$env('HOST').';port='.$env('PORT'), chr(96), chr(96), 'SELECT 1 FROM information_schema.schemata WHERE schema_name = ?'],
+    'pgsql' => ['pgsql:host='.$env('HOST').';port='.$env('PORT').';dbname=postgres', '"', '"', 'SELECT 1 FROM pg_database WHERE datname = ?'],
+    'sqlsrv' => ['sqlsrv:Server='.$env('HOST').','.$env('PORT').';TrustServerCertificate=1', '[', ']', 'SELECT 1 FROM sys.databases WHERE name = ?'],
+};
+if (! in_array(strtok($dsn, ':'), PDO::getAvailableDrivers(), true)) {
+    fwrite(STDERR, 'The pdo_'.strtok($dsn, ':')." PHP extension is not installed\n");
+    exit(4);
+}
+try {
+    $pdo = new PDO($dsn, $env('USERNAME'), $env('PASSWORD'), [PDO::ATTR_ERRMODE => PDO::ERRMODE_EXCEPTION]);
+} catch (PDOException $e) {
+    fwrite(STDERR, $e->getMessage()."\n");
+    exit(2);
+}
+foreach (explode(',', $env('NAMES')) as $name) {
+    $quoted = $open.str_replace($close, $close.$close, $name).$close;
+    try {
+        if ($env('ACTION') === 'drop') {
+            $pdo->exec("DROP DATABASE IF EXISTS $quoted");
+            echo "dropped $name\n";
+            continue;
+        }
+        $statement = $pdo->prepare($exists);
+        $statement->execute([$name]);
+        if ($statement->fetchColumn() === false) {
+            $pdo->exec("CREATE DATABASE $quoted");
+            echo "created $name\n";
+            continue;
+        }
+    } catch (PDOException $e) {
+        [$state, $code] = $e->errorInfo ?? [$e->getCode(), 0];
+        if (! ($state === '42P04' || in_array($code, [1007, 1801]))) {
+            fwrite(STDERR, $e->getMessage()."\n");
+            exit($state === '42501' || in_array($code, [1044, 1227, 262]) ? 3 : 1);
+        }
+    }
+    echo "exists $name\n";
+}`
+
+// Exit codes of databaseScript.
+const (
+	databaseScriptConnectionFailed = 2
+	databaseScriptPermissionDenied = 3
+	databaseScriptMissingDriver    = 4
+)
+
+// databaseDropTimeout bounds dropping the created databases on rollback.
+const databaseDropTimeout = 30 * time.Second
+
+var (
+	createDatabase bool
+	testDatabases  []string
+)
+
+// databaseCommand runs databaseScript for action ("create" or "drop"). The
+// credentials are passed in the environment rather than as arguments, so they
+// never show up in the process list or in the command log.
+func databaseCommand(projectDir, action string, driver *databaseDriver, settings databaseSettings, names []string) Command {
+	if settings.Host == "" {
+		settings.Host = "127.0.0.1"
+	}
+	if settings.Port == "" {
+		settings.Port = strconv.Itoa(driver.Port)
+	}
+	if settings.Username == "" {
+		settings.Username = "root"
+	}
+
+	cmd := Command{Name: "php", Args: []string{"-r", databaseScript}, Dir: projectDir}
+	cmd.Env = []string{
+		"LARAVEL_DB_ACTION=" + action,
+		"LARAVEL_DB_DRIVER=" + driver.Protocol,
+		"LARAVEL_DB_HOST=" + settings.Host,
+		"LARAVEL_DB_PORT=" + settings.Port,
+		"LARAVEL_DB_USERNAME=" + settings.Username,
+		"LARAVEL_DB_PASSWORD=" + settings.Password,
+		"LARAVEL_DB_NAMES=" + strings.Join(names, ","),
+	}
+	if settings.Socket != "" {
+		cmd.Env = append(cmd.Env, "LARAVEL_DB_SOCKET="+settings.Socket)
+	}
+	return cmd
+}
+
+// applicationDatabases returns DB_DATABASE followed by the --test-database names.
+func applicationDatabases(settings databaseSettings) []string {
+	names := []string{}
+	if settings.Database != "" {
+		names = append(names, settings.Database)
+	}
+	for _, name := range testDatabases {
+		if !contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// createDatabases creates the application database and the test databases
+// on the configured server. Databases that already exist are left alone;
+// the ones it creates are dropped again if the installation is rolled back.
+func createDatabases(ctx context.Context, tx *transaction, projectDir, driverName string) error {
+	driver := lookupDriver(driverName)
+	if driver == nil {
+		return warn(ctx, exitDatabaseFailed, fmt.Errorf("unknown driver %q", driverName), "Could not create the database")
+	}
+	if !driver.canCreateDatabases() {
+		if !quiet {
+			fmt.Printf("Skipped creating the database: %s creates databases when they are first used\n", driver.DisplayName)
+		}
+		return nil
+	}
+	settings, err := loadDatabaseSettings(filepath.Join(projectDir, ".env"), driver.Keys)
+	if err != nil {
+		return warn(ctx, exitDatabaseFailed, err, "Could not read the database settings")
+	}
+	names := applicationDatabases(settings)
+	if len(names) == 0 {
+		return warn(ctx, exitDatabaseFailed, errors.New("DB_DATABASE is not set"), "Could not create the database")
+	}
+
+	if !quiet {
+		fmt.Printf("Creating %s...\n", strings.Join(names, ", "))
+	}
+	result, err := runCommand(ctx, databaseCommand(projectDir, "create", driver, settings, names))
+	if err != nil {
+		return warnDatabaseFailure(ctx, err, driver, settings, names)
+	}
+
+	var created []string
+	for _, line := range strings.Split(strings.TrimSpace(string(result.Stdout)), "\n") {
+		status, name, _ := strings.Cut(line, " ")
+		switch status {
+		case "created":
+			created = append(created, name)
+			if !quiet {
+				fmt.Printf("Created the database %s\n", name)
+			}
+		case "exists":
+			if !quiet {
+				fmt.Printf("The database %s already exists\n", name)
+			}
+		}
+	}
+
+	if len(created) > 0 {
+		tx.record("drop the databases "+strings.Join(created, ", "), func() error {
+			cmd := databaseCommand(projectDir, "drop", driver, settings, created)
+			cmd.Timeout = databaseDropTimeout
+			if _, err := runCommand(context.Background(), cmd); err != nil {
+				return fmt.Errorf("%v (drop them manually)", databaseFailureReason(err))
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// warnDatabaseFailure explains why databaseScript failed.
+func warnDatabaseFailure(ctx context.Context, err error, driver *databaseDriver, settings databaseSettings, names []string) error {
+	var cmdErr *CommandError
+	exitCode := -1
+	if errors.As(err, &cmdErr) {
+		exitCode = cmdErr.ExitCode
+	}
+	reason := databaseFailureReason(err)
+	name := driver.DisplayName
+
+	switch exitCode {
+	case databaseScriptPermissionDenied:
+		username := settings.Username
+		if username == "" {
+			username = "root"
+		}
+		return warn(ctx, exitDatabaseFailed, reason, fmt.Sprintf(
+			"The %s user %q is not allowed to create databases. Create %s as an administrator, or grant the user the privilege to create databases, then run `php artisan migrate`",
+			name, username, strings.Join(names, ", ")))
+	case databaseScriptMissingDriver:
+		return warn(ctx, exitDatabaseFailed, reason, fmt.Sprintf("PHP cannot connect to %s", name))
+	case databaseScriptConnectionFailed:
+		return warn(ctx, exitDatabaseFailed, reason, fmt.Sprintf("Could not connect to %s to create the database", name))
+	default:
+		return warn(ctx, exitDatabaseFailed, reason, "Could not create the database")
+	}
+}
+
+// databaseFailureReason prefers the message PHP printed over the exit status.
+func databaseFailureReason(err error) error {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) && strings.TrimSpace(cmdErr.Stderr) != "" {
+		return errors.New(strings.TrimSpace(cmdErr.Stderr))
+	}
+	return err
+}
+
+// sqliteDatabasePath returns the database file the settings point at:
+// DB_DATABASE, or the path of DB_URL, relative to the project directory, or
+// Laravel's default of database/database.sqlite.
+func sqliteDatabasePath(projectDir string, settings databaseSettings) string {
+	if settings.URL != "" {
+		settings.applyURL(settings.URL)
+	}
+	switch {
+	case settings.Database == "":
+		return filepath.Join(projectDir, "database", "database.sqlite")
+	case filepath.IsAbs(settings.Database):
+		return settings.Database
+	default:
+		return filepath.Join(projectDir, settings.Database)
+	}
+}
+
+// createSQLiteDatabase creates an empty database file and its directories.
+// An existing file is kept, since it may already hold data.
+func createSQLiteDatabase(tx *transaction, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	// Remove the outermost directory created for the file on rollback
+	dir := filepath.Dir(path)
+	missing := ""
+	for parent := dir; ; parent = filepath.Dir(parent) {
+		if _, err := os.Stat(parent); err == nil || parent == filepath.Dir(parent) {
+			break
+		}
+		missing = parent
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if missing != "" {
+		tx.recordPath("remove "+missing, missing)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	tx.recordPath("remove "+path, path)
+	return file.Close()
+}
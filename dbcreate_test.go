@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// databaseScriptHandler answers databaseScript invocations with stdout, or
+// fails them with exitCode and stderr, and runs the fake skeleton otherwise.
+func databaseScriptHandler(stdout string, exitCode int, stderr string) func(cmd Command) (*Result, error) {
+	return func(cmd Command) (*Result, error) {
+		if cmd.Name == "php" && len(cmd.Args) > 1 && cmd.Args[1] == databaseScript {
+			if exitCode != 0 {
+				return &Result{ExitCode: exitCode}, &CommandError{Command: cmd, ExitCode: exitCode, Stderr: stderr}
+			}
+			return &Result{Stdout: []byte(stdout)}, nil
+		}
+		return fakeSkeletonHandler(cmd)
+	}
+}
+
+// databaseScriptRuns returns the environment of every databaseScript run.
+func databaseScriptRuns(fake *fakeRunner) [][]string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	var runs [][]string
+	for _, cmd := range fake.commands {
+		if cmd.Name == "php" && len(cmd.Args) > 1 && cmd.Args[1] == databaseScript {
+			runs = append(runs, cmd.Env)
+		}
+	}
+	return runs
+}
+
+func TestSQLiteDatabasePath(t *testing.T) {
+	projectDir := filepath.Join("projects", "demo")
+	testCases := []struct {
+		settings databaseSettings
+		want     string
+	}{
+		{databaseSettings{}, filepath.Join(projectDir, "database", "database.sqlite")},
+		{databaseSettings{Database: "storage/app.sqlite"}, filepath.Join(projectDir, "storage", "app.sqlite")},
+		{databaseSettings{Database: "/var/data/app.sqlite"}, "/var/data/app.sqlite"},
+		{databaseSettings{URL: "sqlite:////var/data/app.sqlite"}, "/var/data/app.sqlite"},
+	}
+	for _, tc := range testCases {
+		if got := sqliteDatabasePath(projectDir, tc.settings); got != filepath.FromSlash(tc.want) {
+			t.Errorf("sqliteDatabasePath(%+v) = %q; want %q", tc.settings, got, tc.want)
+		}
+	}
+}
+
+func TestCreateSQLiteDatabase(t *testing.T) {
+	dir := t.TempDir()
+	tx := &transaction{}
+
+	path := filepath.Join(dir, "storage", "db", "app.sqlite")
+	if err := createSQLiteDatabase(tx, path); err != nil {
+		t.Fatalf("createSQLiteDatabase returned error: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Expected the database file to be created: %v", err)
+	}
+
+	existing := filepath.Join(dir, "existing.sqlite")
+	writeTestFile(existing, "data")
+	if err := createSQLiteDatabase(tx, existing); err != nil {
+		t.Fatalf("createSQLiteDatabase returned error: %v", err)
+	}
+
+	tx.rollback()
+	if _, err := os.Stat(filepath.Join(dir, "storage")); !os.IsNotExist(err) {
+		t.Error("Expected the created directories to be removed on rollback")
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "data" {
+		t.Error("Expected an existing database to be kept")
+	}
+}
+
+func TestCreateDatabaseFlag(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	fake.handler = databaseScriptHandler("created shop\nexists shop_testing\n", 0, "")
+	quiet, noInteraction, database = true, true, "mysql"
+	dbDatabase, dbPassword, testDatabases = "shop", "s3cret", []string{"shop_testing"}
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	runs := databaseScriptRuns(fake)
+	if len(runs) != 1 {
+		t.Fatalf("Expected the database script to run once, got %d runs", len(runs))
+	}
+	env := strings.Join(runs[0], "\n")
+	for _, want := range []string{"LARAVEL_DB_ACTION=create", "LARAVEL_DB_DRIVER=mysql", "LARAVEL_DB_PORT=3306", "LARAVEL_DB_PASSWORD=s3cret", "LARAVEL_DB_NAMES=shop,shop_testing"} {
+		if !strings.Contains(env, want) {
+			t.Errorf("Expected the environment to contain %q, got:\n%s", want, env)
+		}
+	}
+	if strings.Contains(strings.Join(fake.commandLines(), "\n"), "s3cret") {
+		t.Error("Expected the password to stay out of the command line")
+	}
+}
+
+func TestCreatedDatabasesAreDroppedOnRollback(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	fake.handler = databaseScriptHandler("created shop\nexists shop_testing\n", 0, "")
+	quiet, testDatabases = true, []string{"shop_testing"}
+	writeTestFile(".env", "DB_CONNECTION=pgsql\nDB_DATABASE=shop\n")
+
+	tx := &transaction{}
+	if err := createDatabases(context.Background(), tx, ".", "pgsql"); err != nil {
+		t.Fatalf("createDatabases returned error: %v", err)
+	}
+	if errs := tx.rollback(); len(errs) > 0 {
+		t.Fatalf("rollback returned errors: %v", errs)
+	}
+
+	runs := databaseScriptRuns(fake)
+	if len(runs) != 2 {
+		t.Fatalf("Expected the databases to be created and dropped, got %d runs", len(runs))
+	}
+	if !contains(runs[1], "LARAVEL_DB_ACTION=drop") || !contains(runs[1], "LARAVEL_DB_NAMES=shop") {
+		t.Errorf("Expected only the created database to be dropped, got:\n%s", strings.Join(runs[1], "\n"))
+	}
+}
+
+func TestCreateDatabasePermissionDenied(t *testing.T) {
+	denied := "SQLSTATE[42501]: Insufficient privilege: 7 ERROR:  permission denied to create database"
+
+	t.Run("Warning", func(t *testing.T) {
+		fake := useFakeRunner(t, "")
+		fake.handler = databaseScriptHandler("", databaseScriptPermissionDenied, denied)
+		quiet, noInteraction, database, createDatabase = true, true, "pgsql", true
+
+		if err := createNewProject("demo"); err != nil {
+			t.Fatalf("Expected a privilege error to be a warning, got %v", err)
+		}
+		if _, err := os.Stat(filepath.Join("demo", ".env")); err != nil {
+			t.Error("Expected the installation to continue")
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		fake := useFakeRunner(t, "")
+		fake.handler = databaseScriptHandler("", databaseScriptPermissionDenied, denied)
+		quiet, noInteraction, strict, database, createDatabase = true, true, true, "pgsql", true
+		dbUsername = "shop"
+
+		err := createNewProject("demo")
+		if exitCodeFor(err) != exitDatabaseFailed || !strings.Contains(err.Error(), `The PostgreSQL user "shop" is not allowed to create databases`) || !strings.Contains(err.Error(), "permission denied to create database") {
+			t.Errorf("Expected exit code %d with a privilege error, got %v", exitDatabaseFailed, err)
+		}
+	})
+}
+
+func TestCreateDatabasePromptBeforeMigrating(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	// Default URL, run the migrations, create the database, no npm
+	fake := useFakeRunner(t, "\ny\ny\nn\n")
+	fake.handler = databaseScriptHandler("created demo\n", 0, "")
+	useDatabasePort(listener.Addr().(*net.TCPAddr).Port)
+	quiet, database = true, "mysql"
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	commands := fake.commandLines()
+	created, migrated := -1, -1
+	for i, line := range commands {
+		if strings.HasPrefix(line, "php -r ") {
+			created = i
+		}
+		if line == "php artisan migrate --no-interaction" {
+			migrated = i
+		}
+	}
+	if created < 0 || migrated < created {
+		t.Errorf("Expected the database to be created before migrating, got:\n%s", strings.Join(commands, "\n"))
+	}
+}
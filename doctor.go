@@ -0,0 +1,409 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Statuses of a doctor check.
+const (
+	checkPass = "pass"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// doctorCheck is one line of the `laravel doctor` report.
+type doctorCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+
+	// undetermined is set when the tool answered with something that could
+	// not be read, e.g. a version string in an unknown format. `laravel
+	// new` does not warn about these.
+	undetermined bool
+}
+
+func (c doctorCheck) String() string {
+	if c.Hint == "" {
+		return fmt.Sprintf("%s: %s", c.Name, c.Message)
+	}
+	return fmt.Sprintf("%s: %s. %s", c.Name, c.Message, c.Hint)
+}
+
+// doctorScope selects the checks that are relevant to an installation.
+type doctorScope struct {
+	StarterKit string
+	Dev        bool
+	Drivers    []*databaseDriver // Drivers whose PHP extensions are reported
+	Database   string            // The chosen driver; its missing extensions fail
+	Node       *packageManager   // Checked together with Node when set
+	Git        bool
+	GitHub     bool
+}
+
+// phpRequirement is the oldest PHP version a skeleton supports.
+type phpRequirement struct {
+	Name    string
+	Version string
+}
+
+// phpRequirements lists the requirements of the Laravel skeleton and the
+// official starter kits. Community starter kits are held to the skeleton's.
+var phpRequirements = map[string]phpRequirement{
+	"":                             {"Laravel", "8.2"},
+	"laravel/react-starter-kit":    {"The React starter kit", "8.2"},
+	"laravel/vue-starter-kit":      {"The Vue starter kit", "8.2"},
+	"laravel/livewire-starter-kit": {"The Livewire starter kit", "8.2"},
+}
+
+// devPHPRequirement applies to the development release (--dev).
+var devPHPRequirement = phpRequirement{"The development release of Laravel", "8.3"}
+
+// laravelExtensions are the PHP extensions the framework itself needs.
+var laravelExtensions = []string{"ctype", "curl", "dom", "fileinfo", "filter", "hash", "mbstring", "openssl", "pcre", "pdo", "session", "tokenizer", "xml"}
+
+// Oldest supported versions of the other tools.
+const (
+	minComposerVersion = "2.2"
+	minNodeVersion     = "20.19" // Vite 7, used by the starter kits
+)
+
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// parseVersion returns the first version number in output.
+func parseVersion(output string) string {
+	return versionPattern.FindString(output)
+}
+
+// versionAtLeast reports whether version is the same as or newer than
+// minimum. Missing parts count as zero.
+func versionAtLeast(version, minimum string) bool {
+	have, want := strings.Split(version, "."), strings.Split(minimum, ".")
+	for i := 0; i < len(want); i++ {
+		var a, b int
+		if i < len(have) {
+			a, _ = strconv.Atoi(have[i])
+		}
+		b, _ = strconv.Atoi(want[i])
+		if a != b {
+			return a > b
+		}
+	}
+	return true
+}
+
+func requiredPHPVersion(starterKit string, dev bool) phpRequirement {
+	requirement, ok := phpRequirements[strings.SplitN(starterKit, ":", 2)[0]]
+	if !ok {
+		requirement = phpRequirements[""]
+	}
+	if dev && !versionAtLeast(requirement.Version, devPHPRequirement.Version) {
+		requirement = devPHPRequirement
+	}
+	return requirement
+}
+
+// toolOutput runs a diagnostic command and returns its combined output.
+func toolOutput(ctx context.Context, name string, args ...string) (string, error) {
+	result, err := runCommand(ctx, Command{Name: name, Args: args, Timeout: 30 * time.Second})
+	if result == nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(result.Stdout) + "\n" + string(result.Stderr)), err
+}
+
+// checkVersion reports the version of a tool against the oldest one that
+// is supported. A missing tool is a failure when required, a warning
+// otherwise.
+func checkVersion(ctx context.Context, name, tool string, required bool, minimum, hint string, args ...string) (doctorCheck, bool) {
+	check := doctorCheck{Name: name}
+	missing := checkWarn
+	if required {
+		missing = checkFail
+	}
+
+	if _, err := runner.LookPath(tool); err != nil {
+		check.Status, check.Message, check.Hint = missing, "not found in PATH", hint
+		return check, false
+	}
+
+	output, _ := toolOutput(ctx, tool, args...)
+	version := parseVersion(output)
+	switch {
+	case version == "":
+		check.Status, check.Message, check.undetermined = checkWarn, "installed, but the version could not be determined", true
+	case minimum != "" && !versionAtLeast(version, minimum):
+		check.Status, check.Message, check.Hint = missing, fmt.Sprintf("%s is installed, %s or newer is needed", version, minimum), hint
+	default:
+		check.Status, check.Message = checkPass, version
+	}
+	return check, true
+}
+
+func checkPHP(ctx context.Context, scope doctorScope) []doctorCheck {
+	requirement := requiredPHPVersion(scope.StarterKit, scope.Dev)
+	check, found := checkVersion(ctx, "PHP", "php", true, requirement.Version,
+		fmt.Sprintf("%s needs PHP %s or newer: https://www.php.net/downloads", requirement.Name, requirement.Version), "-v")
+	if check.Status == checkPass {
+		check.Message += fmt.Sprintf(" (%s needs %s or newer)", requirement.Name, requirement.Version)
+	}
+	checks := []doctorCheck{check}
+	if !found {
+		return checks
+	}
+
+	loaded := phpExtensions(ctx, "")
+	if loaded == nil {
+		return append(checks, doctorCheck{Name: "PHP extensions", Status: checkWarn, Message: "php -m did not list any extensions", undetermined: true})
+	}
+
+	extensions := doctorCheck{Name: "PHP extensions", Status: checkPass, Message: strings.Join(laravelExtensions, ", ")}
+	if missing := unloadedExtensions(loaded, laravelExtensions); len(missing) > 0 {
+		extensions.Status, extensions.Message = checkFail, "missing "+strings.Join(missing, ", ")
+		extensions.Hint = "Laravel needs " + strings.Join(laravelExtensions, ", ")
+	}
+	checks = append(checks, extensions)
+
+	// One line per extension, naming the drivers that use it
+	var names []string
+	users := map[string][]string{}
+	chosen := map[string]bool{}
+	for _, driver := range scope.Drivers {
+		for _, extension := range driver.Extensions {
+			if _, ok := users[extension]; !ok {
+				names = append(names, extension)
+			}
+			users[extension] = append(users[extension], driver.DisplayName)
+			if driver.Name == scope.Database {
+				chosen[extension] = true
+			}
+		}
+	}
+	for _, extension := range names {
+		check := doctorCheck{Name: extension + " extension", Status: checkPass, Message: "loaded, used by " + joinNames(users[extension])}
+		if len(unloadedExtensions(loaded, []string{extension})) > 0 {
+			check.Status, check.Message = checkWarn, "not loaded, needed by "+joinNames(users[extension])
+			check.Hint = fmt.Sprintf("Install or enable the %s extension before using this driver", extension)
+			if chosen[extension] {
+				check.Status = checkFail
+			}
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// joinNames lists names as "A, B and C".
+func joinNames(names []string) string {
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+func checkComposer(ctx context.Context) doctorCheck {
+	check, _ := checkVersion(ctx, "Composer", "composer", true, minComposerVersion,
+		"Install Composer: https://getcomposer.org/", "--version", "--no-ansi")
+	return check
+}
+
+func checkNode(ctx context.Context, manager *packageManager) []doctorCheck {
+	node, _ := checkVersion(ctx, "Node", "node", false, minNodeVersion,
+		fmt.Sprintf("Install Node %s or newer to build the frontend: https://nodejs.org/", minNodeVersion), "--version")
+	managerCheck, _ := checkVersion(ctx, manager.Name, manager.Name, false, "", manager.Hint, "--version")
+	return []doctorCheck{node, managerCheck}
+}
+
+func checkGit(ctx context.Context) []doctorCheck {
+	check, found := checkVersion(ctx, "Git", "git", false, "", "Install Git: https://git-scm.com/downloads", "--version")
+	checks := []doctorCheck{check}
+	if !found {
+		return checks
+	}
+
+	// git config exits with status 1 when the key is not set
+	identity := doctorCheck{Name: "Git identity", Status: checkPass}
+	var values, unset []string
+	for _, key := range []string{"user.name", "user.email"} {
+		value, err := toolOutput(ctx, "git", "config", "--get", key)
+		if err != nil {
+			unset = append(unset, key)
+			continue
+		}
+		values = append(values, value)
+	}
+	if len(unset) > 0 {
+		identity.Status, identity.Message = checkWarn, strings.Join(unset, " and ")+" not set, so the initial commit will fail"
+		identity.Hint = fmt.Sprintf("Run git config --global %s ...", unset[0])
+	} else {
+		identity.Message = fmt.Sprintf("%s <%s>", values[0], values[1])
+	}
+	return append(checks, identity)
+}
+
+func checkGitHub(ctx context.Context) doctorCheck {
+	check, found := checkVersion(ctx, "GitHub CLI", "gh", false, "", "Install the GitHub CLI: https://cli.github.com/", "--version")
+	if !found || check.Status != checkPass {
+		return check
+	}
+
+	version := check.Message
+	if _, err := toolOutput(ctx, "gh", githubAuthArgs...); err != nil {
+		check.Status, check.Message, check.Hint = checkWarn, version+", not logged in", "Run gh auth login"
+	} else {
+		check.Message = version + ", logged in"
+	}
+	return check
+}
+
+// runDoctor runs the checks in scope, in the order they are reported.
+func runDoctor(ctx context.Context, scope doctorScope) []doctorCheck {
+	checks := checkPHP(ctx, scope)
+	checks = append(checks, checkComposer(ctx))
+	if scope.Node != nil {
+		checks = append(checks, checkNode(ctx, scope.Node)...)
+	}
+	if scope.Git {
+		checks = append(checks, checkGit(ctx)...)
+	}
+	if scope.GitHub {
+		checks = append(checks, checkGitHub(ctx))
+	}
+	return checks
+}
+
+// doctorCommands lists the commands runDoctor runs for scope. The ones that
+// follow a version check only run when the tool is found.
+func doctorCommands(scope doctorScope) [][]string {
+	commands := [][]string{{"php", "-v"}, {"php", "-m"}, {"composer", "--version", "--no-ansi"}}
+	if scope.Node != nil {
+		commands = append(commands, []string{"node", "--version"}, []string{scope.Node.Name, "--version"})
+	}
+	if scope.Git {
+		commands = append(commands, []string{"git", "--version"},
+			[]string{"git", "config", "--get", "user.name"}, []string{"git", "config", "--get", "user.email"})
+	}
+	if scope.GitHub {
+		commands = append(commands, []string{"gh", "--version"}, append([]string{"gh"}, githubAuthArgs...))
+	}
+	return commands
+}
+
+// newDoctorScope returns the checks that matter for the `laravel new` flags.
+// Driver extensions are left out: they are checked once the driver has been
+// chosen, and a missing one does not stop the installation.
+func newDoctorScope() doctorScope {
+	return doctorScope{
+		StarterKit: getStarterKit(),
+		Dev:        dev,
+		Node:       selectedPackageManager(),
+		Git:        git || github != "",
+		GitHub:     github != "",
+	}
+}
+
+// ensureRequiredTools runs the relevant doctor checks before anything is
+// created. Failures stop the installation, warnings are shown.
+func ensureRequiredTools(ctx context.Context) error {
+	var failures []string
+	for _, check := range runDoctor(ctx, newDoctorScope()) {
+		switch {
+		case check.Status == checkFail:
+			failures = append(failures, check.String())
+		case check.Status == checkWarn && !check.undetermined:
+			printWarning(ctx, check.String())
+		}
+	}
+	if len(failures) > 0 {
+		return exitErrorf(exitMissingTool, "%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
+
+// doctorReport is the --format=json output of `laravel doctor`.
+type doctorReport struct {
+	Status string        `json:"status"`
+	Checks []doctorCheck `json:"checks"`
+}
+
+func newDoctorReport(checks []doctorCheck) *doctorReport {
+	report := &doctorReport{Status: checkPass, Checks: checks}
+	for _, check := range checks {
+		if check.Status == checkFail || (check.Status == checkWarn && report.Status == checkPass) {
+			report.Status = check.Status
+		}
+	}
+	return report
+}
+
+func printDoctorReport(report *doctorReport, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	for _, check := range report.Checks {
+		fmt.Fprintf(stdout, "%-20s %-5s %s\n", check.Name, check.Status, check.Message)
+		if check.Hint != "" && check.Status != checkPass {
+			fmt.Fprintf(stdout, "%-20s %-5s %s\n", "", "", check.Hint)
+		}
+	}
+	return nil
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that PHP, Composer and the other tools are ready for Laravel",
+	Long: "Check the PHP version against what Laravel and the chosen starter kit need, the loaded PHP\n" +
+		"extensions, including the ones each database driver uses, and the Composer, Node, npm, Git\n" +
+		"and GitHub CLI installations. Each check passes, warns or fails.\n\n" +
+		"The --database, --dev and starter kit flags select what is checked, like for `laravel new`,\n" +
+		"which runs the checks relevant to its flags before it creates the project.\n\n" +
+		"Exits with status 15 when a check fails.",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyConfigDefaults(newCmd.Flags())
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat != "text" && outputFormat != "json" {
+			return exitErrorf(exitUsage, "Invalid format [%s]. Possible values are: text, json", outputFormat)
+		}
+		if database != "" && lookupDriver(database) == nil {
+			return exitErrorf(exitUsage, "Invalid database driver [%s]. Possible values are: %s",
+				database, strings.Join(driverNames(), ", "))
+		}
+
+		scope := doctorScope{
+			StarterKit: getStarterKit(),
+			Dev:        dev,
+			Drivers:    registeredDrivers,
+			Database:   database,
+			Node:       projectPackageManager("."),
+			Git:        true,
+			GitHub:     true,
+		}
+		report := newDoctorReport(runDoctor(context.Background(), scope))
+		if err := printDoctorReport(report, outputFormat); err != nil {
+			return err
+		}
+		if report.Status == checkFail {
+			return exitErrorf(exitDoctorFailed, "some checks failed")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
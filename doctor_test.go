@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// useDoctorRunner answers the doctor's commands from outputs, keyed by
+// command line. Commands without an output fail.
+func useDoctorRunner(t *testing.T, outputs map[string]string) *fakeRunner {
+	t.Helper()
+	fake := useFakeRunner(t, "")
+	fake.handler = func(cmd Command) (*Result, error) {
+		if output, ok := outputs[cmd.String()]; ok {
+			return &Result{Stdout: []byte(output)}, nil
+		}
+		if cmd.Name == "composer" && len(cmd.Args) > 0 && cmd.Args[0] == "create-project" {
+			return fakeSkeletonHandler(cmd)
+		}
+		return &Result{ExitCode: 1}, &CommandError{Command: cmd, ExitCode: 1}
+	}
+	return fake
+}
+
+func healthyToolchain() map[string]string {
+	return map[string]string{
+		"php -v":                       "PHP 8.3.12 (cli) (built: Sep 24 2024 18:08:04) (NTS)\nCopyright (c) The PHP Group\n",
+		"php -m":                       "[PHP Modules]\n" + strings.Join(laravelExtensions, "\n") + "\npdo_mysql\npdo_sqlite\n\n[Zend Modules]\n",
+		"composer --version --no-ansi": "Composer version 2.8.1 2024-10-04 11:31:01\nPHP version 8.3.12 (/usr/bin/php)\n",
+		"node --version":               "v22.11.0\n",
+		"npm --version":                "10.9.0\n",
+		"git --version":                "git version 2.47.0\n",
+		"git config --get user.name":   "Taylor\n",
+		"git config --get user.email":  "taylor@example.com\n",
+		"gh --version":                 "gh version 2.60.1 (2024-10-25)\n",
+		"gh auth status":               "github.com\n  ✓ Logged in to github.com account taylor (keyring)\n",
+	}
+}
+
+func TestDoctorReport(t *testing.T) {
+	outputs := healthyToolchain()
+	delete(outputs, "git config --get user.email")
+	delete(outputs, "gh auth status")
+	useDoctorRunner(t, outputs)
+	var out bytes.Buffer
+	stdout = &out
+	database = "pgsql"
+
+	err := doctorCmd.RunE(doctorCmd, nil)
+	if exitCodeFor(err) != exitDoctorFailed {
+		t.Fatalf("Expected the missing pdo_pgsql extension to fail, got %v", err)
+	}
+
+	report := out.String()
+	for _, want := range []string{
+		"PHP                  pass  8.3.12 (Laravel needs 8.2 or newer)\n",
+		"Composer             pass  2.8.1\n",
+		"pdo_mysql extension  pass  loaded, used by MySQL, MariaDB and SingleStore\n",
+		"pdo_pgsql extension  fail  not loaded, needed by PostgreSQL\n",
+		"pdo_sqlsrv extension warn  not loaded, needed by SQL Server\n",
+		"Git identity         warn  user.email not set, so the initial commit will fail\n",
+		"                           Run git config --global user.email ...\n",
+		"GitHub CLI           warn  2.60.1, not logged in\n",
+		"Node                 pass  22.11.0\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Expected the report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestDoctorJSON(t *testing.T) {
+	outputs := healthyToolchain()
+	outputs["node --version"] = "v18.20.4\n"
+	useDoctorRunner(t, outputs)
+	var out bytes.Buffer
+	stdout = &out
+	outputFormat, react = "json", true
+
+	if err := doctorCmd.RunE(doctorCmd, nil); err != nil {
+		t.Fatalf("Expected warnings only, got %v", err)
+	}
+
+	var report doctorReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	if report.Status != checkWarn {
+		t.Errorf("Expected the report to warn, got %s", report.Status)
+	}
+	for _, check := range report.Checks {
+		switch check.Name {
+		case "PHP":
+			if check.Message != "8.3.12 (The React starter kit needs 8.2 or newer)" {
+				t.Errorf("Unexpected PHP check: %+v", check)
+			}
+		case "Node":
+			if check.Status != checkWarn || check.Message != "18.20.4 is installed, 20.19 or newer is needed" {
+				t.Errorf("Unexpected Node check: %+v", check)
+			}
+		}
+	}
+}
+
+func TestNewChecksTheToolchainFirst(t *testing.T) {
+	outputs := healthyToolchain()
+	outputs["php -v"] = "PHP 8.2.24 (cli)\n"
+	fake := useDoctorRunner(t, outputs)
+	quiet, noInteraction, dev = true, true, true
+
+	err := createNewProject("shop")
+	if exitCodeFor(err) != exitMissingTool || !strings.Contains(err.Error(), "PHP: 8.2.24 is installed, 8.3 or newer is needed") {
+		t.Fatalf("Expected the PHP version to be rejected, got %v", err)
+	}
+	for _, line := range fake.commandLines() {
+		if strings.HasPrefix(line, "composer create-project") {
+			t.Error("Expected the project not to be created")
+		}
+	}
+	if contains(fake.commandLines(), "node --version") || contains(fake.commandLines(), "git --version") {
+		t.Errorf("Expected only the relevant checks, got %v", fake.commandLines())
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	testCases := []struct {
+		version, minimum string
+		want             bool
+	}{
+		{"8.3.12", "8.2", true},
+		{"8.2", "8.2", true},
+		{"8.1.30", "8.2", false},
+		{"20.9.0", "20.19", false},
+		{"22.0.0", "20.19", true},
+		{"1.10.27", "2.2", false},
+	}
+	for _, tc := range testCases {
+		if got := versionAtLeast(tc.version, tc.minimum); got != tc.want {
+			t.Errorf("versionAtLeast(%q, %q) = %v; want %v", tc.version, tc.minimum, got, tc.want)
+		}
+	}
+}
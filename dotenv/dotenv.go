@@ -0,0 +1,491 @@
+// Package dotenv reads and edits .env files without losing their layout.
+//
+// A file is parsed into a list of lines that keeps comments, blank lines,
+// ordering, `export` prefixes, quoting style and line endings. Lines that are
+// not changed are written back byte for byte, so editing one key produces a
+// one line diff.
+//
+// The syntax follows vlucas/phpdotenv, which Laravel uses:
+//
+//	APP_NAME="My App"          # double quotes support escapes and newlines
+//	APP_KEY='base64:...'       # single quotes are literal
+//	export APP_ENV=local       # an export prefix is allowed
+//	# DB_HOST=127.0.0.1        # a commented out assignment
+//
+// Variable references such as ${APP_NAME} are kept as written; they are not
+// expanded.
+package dotenv
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Kind is the type of a line.
+type Kind int
+
+const (
+	Blank      Kind = iota // An empty or whitespace only line
+	Comment                // A comment that is not an assignment
+	Assignment             // KEY=value, active or commented out
+)
+
+// Line is a single logical line. Quoted values may span several physical
+// lines; they are still one Line.
+type Line struct {
+	Kind      Kind
+	Key       string
+	Value     string // The unescaped value
+	Quote     byte   // 0, '\'' or '"'
+	Export    bool   // Written with an `export ` prefix
+	Commented bool   // A commented out assignment such as `# DB_HOST=127.0.0.1`
+	Comment   string // Trailing inline comment, including the leading #
+
+	raw      string // Original text, lines joined with \n
+	modified bool
+}
+
+// File is a parsed .env file.
+type File struct {
+	Lines []*Line
+
+	eol             string // "\n" or "\r\n"
+	trailingNewline bool
+}
+
+// ParseError reports a syntax error at a line.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+var keyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// ValidKey reports whether key can be used as a variable name.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// Format renders a KEY=value assignment, quoting the value only when needed.
+func Format(key, value string) string {
+	line := &Line{Kind: Assignment, Key: key, Value: value, Quote: chooseQuote(value, 0), modified: true}
+	return line.String()
+}
+
+// Parse parses the contents of a .env file.
+func Parse(data []byte) (*File, error) {
+	content := string(data)
+	f := &File{eol: "\n", trailingNewline: true}
+	if strings.Contains(content, "\r\n") {
+		f.eol = "\r\n"
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+	if content == "" {
+		return f, nil
+	}
+	f.trailingNewline = strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+
+	physical := strings.Split(content, "\n")
+	for i := 0; i < len(physical); i++ {
+		lineNo := i + 1
+		text := physical[i]
+		trimmed := strings.TrimSpace(text)
+
+		switch {
+		case trimmed == "":
+			f.Lines = append(f.Lines, &Line{Kind: Blank, raw: text})
+			continue
+		case strings.HasPrefix(trimmed, "#"):
+			line := &Line{Kind: Comment, raw: text}
+			// A comment that reads as an assignment is a commented out key
+			body := strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			if parsed, _, err := parseAssignment(body, nil); err == nil {
+				parsed.Commented = true
+				parsed.raw = text
+				line = parsed
+			}
+			f.Lines = append(f.Lines, line)
+			continue
+		}
+
+		line, consumed, err := parseAssignment(text, physical[i+1:])
+		if err != nil {
+			return nil, &ParseError{Line: lineNo, Msg: err.Error()}
+		}
+		line.raw = strings.Join(physical[i:i+consumed+1], "\n")
+		f.Lines = append(f.Lines, line)
+		i += consumed
+	}
+
+	return f, nil
+}
+
+// parseAssignment parses KEY=value. A quoted value may continue on the
+// following lines, in which case the number of extra lines used is returned.
+// Continuation is disabled when rest is nil.
+func parseAssignment(text string, rest []string) (*Line, int, error) {
+	line := &Line{Kind: Assignment}
+	text = strings.TrimLeft(text, " \t")
+	if strings.HasPrefix(text, "export ") {
+		line.Export = true
+		text = strings.TrimLeft(strings.TrimPrefix(text, "export "), " \t")
+	}
+
+	eq := strings.Index(text, "=")
+	if eq < 0 {
+		return nil, 0, fmt.Errorf("expected KEY=value")
+	}
+	line.Key = strings.TrimSpace(text[:eq])
+	if !keyPattern.MatchString(line.Key) {
+		return nil, 0, fmt.Errorf("invalid key %q", line.Key)
+	}
+	rawValue := text[eq+1:]
+	value := strings.TrimLeft(rawValue, " \t")
+
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		// Unquoted values end at a # that follows whitespace
+		for i := 0; i < len(value); i++ {
+			if value[i] != '#' {
+				continue
+			}
+			if (i == 0 && value != rawValue) || (i > 0 && (value[i-1] == ' ' || value[i-1] == '\t')) {
+				line.Comment = value[i:]
+				value = value[:i]
+				break
+			}
+		}
+		line.Value = strings.TrimSpace(value)
+		return line, 0, nil
+	}
+
+	line.Quote = value[0]
+	body := value[1:]
+	consumed := 0
+	for {
+		if end := closingQuote(body, line.Quote); end >= 0 {
+			after := strings.TrimSpace(body[end+1:])
+			if after != "" && !strings.HasPrefix(after, "#") {
+				return nil, 0, fmt.Errorf("unexpected characters after the closing quote of %s", line.Key)
+			}
+			line.Comment = after
+			body = body[:end]
+			break
+		}
+		if consumed >= len(rest) {
+			return nil, 0, fmt.Errorf("unterminated quoted value for %s", line.Key)
+		}
+		body += "\n" + rest[consumed]
+		consumed++
+	}
+
+	if line.Quote == '"' {
+		line.Value = unescape(body)
+	} else {
+		line.Value = body
+	}
+	return line, consumed, nil
+}
+
+// closingQuote returns the index of the unescaped closing quote in s.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// Load reads and parses a .env file.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+// lookup returns the last active assignment of key, which is the one
+// phpdotenv uses.
+func (f *File) lookup(key string) *Line {
+	for i := len(f.Lines) - 1; i >= 0; i-- {
+		line := f.Lines[i]
+		if line.Kind == Assignment && !line.Commented && line.Key == key {
+			return line
+		}
+	}
+	return nil
+}
+
+// Get returns the value of an active key.
+func (f *File) Get(key string) (string, bool) {
+	if line := f.lookup(key); line != nil {
+		return line.Value, true
+	}
+	return "", false
+}
+
+// Has reports whether key is set, ignoring commented out assignments.
+func (f *File) Has(key string) bool {
+	return f.lookup(key) != nil
+}
+
+// Keys returns the active keys in file order, without duplicates.
+func (f *File) Keys() []string {
+	var keys []string
+	seen := map[string]bool{}
+	for _, line := range f.Lines {
+		if line.Kind == Assignment && !line.Commented && !seen[line.Key] {
+			seen[line.Key] = true
+			keys = append(keys, line.Key)
+		}
+	}
+	return keys
+}
+
+// Set updates key in place, keeping its export prefix, inline comment and,
+// when possible, its quoting style. A missing key is appended at the end.
+func (f *File) Set(key, value string) {
+	if line := f.lookup(key); line != nil {
+		if line.Value != value || !canWrite(value, line.Quote) {
+			line.Value = value
+			line.Quote = chooseQuote(value, line.Quote)
+			line.modified = true
+		}
+		return
+	}
+
+	f.Lines = append(f.Lines, &Line{Kind: Assignment, Key: key, Value: value, Quote: chooseQuote(value, 0), modified: true})
+}
+
+// SetAfter is like Set, but inserts a missing key right after the active
+// assignment of after instead of at the end, keeping related keys together.
+func (f *File) SetAfter(key, value, after string) {
+	anchor := f.lookup(after)
+	if f.Has(key) || anchor == nil {
+		f.Set(key, value)
+		return
+	}
+
+	for i, line := range f.Lines {
+		if line == anchor {
+			inserted := &Line{Kind: Assignment, Key: key, Value: value, Quote: chooseQuote(value, 0), modified: true}
+			f.Lines = append(f.Lines[:i+1], append([]*Line{inserted}, f.Lines[i+1:]...)...)
+			return
+		}
+	}
+}
+
+// Unset removes every active assignment of key and reports whether one
+// existed.
+func (f *File) Unset(key string) bool {
+	removed := false
+	lines := f.Lines[:0]
+	for _, line := range f.Lines {
+		if line.Kind == Assignment && !line.Commented && line.Key == key {
+			removed = true
+			continue
+		}
+		lines = append(lines, line)
+	}
+	f.Lines = lines
+	return removed
+}
+
+// Comment turns the active assignments of key into commented out ones.
+func (f *File) Comment(key string) bool {
+	changed := false
+	for _, line := range f.Lines {
+		if line.Kind == Assignment && !line.Commented && line.Key == key {
+			line.Commented = true
+			line.modified = true
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Uncomment activates the last commented out assignment of key, unless key
+// is already active.
+func (f *File) Uncomment(key string) bool {
+	if f.Has(key) {
+		return false
+	}
+	for i := len(f.Lines) - 1; i >= 0; i-- {
+		line := f.Lines[i]
+		if line.Kind == Assignment && line.Commented && line.Key == key {
+			line.Commented = false
+			line.modified = true
+			return true
+		}
+	}
+	return false
+}
+
+// Bytes renders the file, reproducing unchanged lines exactly.
+func (f *File) Bytes() []byte {
+	rendered := make([]string, len(f.Lines))
+	for i, line := range f.Lines {
+		rendered[i] = line.String()
+	}
+
+	content := strings.Join(rendered, "\n")
+	if f.trailingNewline && len(f.Lines) > 0 {
+		content += "\n"
+	}
+	return []byte(strings.ReplaceAll(content, "\n", f.eol))
+}
+
+// String renders a single line.
+func (l *Line) String() string {
+	if !l.modified {
+		return l.raw
+	}
+
+	var b strings.Builder
+	if l.Commented {
+		b.WriteString("# ")
+	}
+	if l.Export {
+		b.WriteString("export ")
+	}
+	b.WriteString(l.Key)
+	b.WriteByte('=')
+	b.WriteString(quote(l.Value, l.Quote))
+	if l.Comment != "" {
+		b.WriteByte(' ')
+		b.WriteString(l.Comment)
+	}
+	return b.String()
+}
+
+// needsQuotes reports whether an unquoted value would be read back differently.
+func needsQuotes(value string) bool {
+	// A # only starts a comment at the beginning or after whitespace
+	return strings.ContainsAny(value, " \t\n\r\"'\\$") || strings.HasPrefix(value, "#")
+}
+
+// canWrite reports whether value can be written with the given quote style.
+func canWrite(value string, quote byte) bool {
+	switch quote {
+	case 0:
+		return !needsQuotes(value)
+	case '\'':
+		return !strings.Contains(value, "'")
+	default:
+		return true
+	}
+}
+
+// chooseQuote keeps the current quote style when it can represent value and
+// otherwise picks the simplest one that can.
+func chooseQuote(value string, current byte) byte {
+	if current != 0 && canWrite(value, current) {
+		return current
+	}
+	if !needsQuotes(value) {
+		return 0
+	}
+	// Single quotes keep $ and backslashes literal
+	if strings.ContainsAny(value, "$\\") && canWrite(value, '\'') {
+		return '\''
+	}
+	return '"'
+}
+
+func quote(value string, quote byte) string {
+	switch quote {
+	case '\'':
+		return "'" + value + "'"
+	case '"':
+		replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\r", `\r`)
+		return `"` + replacer.Replace(value) + `"`
+	default:
+		return value
+	}
+}
+
+// Save writes the file atomically, keeping the permissions of the file it
+// replaces.
+func (f *File) Save(path string) error {
+	return WriteFile(path, f.Bytes())
+}
+
+// WriteFile replaces path with data atomically: the data is written to a
+// temporary file in the same directory which is then renamed over path. The
+// mode of an existing file is kept; new files are created with 0644. When
+// path is a symlink, its target is replaced.
+func WriteFile(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,259 @@
+package dotenv
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+const sample = `APP_NAME="My App"
+APP_ENV=local # the environment
+export APP_KEY='base64:abc='
+APP_URL=http://localhost
+
+# Database
+DB_CONNECTION=sqlite
+# DB_HOST=127.0.0.1
+# DB_PORT=3306
+
+PRIVATE_KEY="-----BEGIN KEY-----
+abc\"def
+-----END KEY-----"
+EMPTY=
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	testCases := map[string]string{
+		"APP_NAME":      "My App",
+		"APP_ENV":       "local",
+		"APP_KEY":       "base64:abc=",
+		"APP_URL":       "http://localhost",
+		"DB_CONNECTION": "sqlite",
+		"PRIVATE_KEY":   "-----BEGIN KEY-----\nabc\"def\n-----END KEY-----",
+		"EMPTY":         "",
+	}
+	for key, want := range testCases {
+		if got, ok := f.Get(key); !ok || got != want {
+			t.Errorf("Get(%s) = %q, %v; want %q", key, got, ok, want)
+		}
+	}
+
+	if f.Has("DB_HOST") {
+		t.Error("Expected commented out keys not to be set")
+	}
+	want := "APP_NAME APP_ENV APP_KEY APP_URL DB_CONNECTION PRIVATE_KEY EMPTY"
+	if got := strings.Join(f.Keys(), " "); got != want {
+		t.Errorf("Keys() = %s; want %s", got, want)
+	}
+}
+
+func TestRoundTripIsExact(t *testing.T) {
+	for _, content := range []string{sample, strings.ReplaceAll(sample, "\n", "\r\n"), "A=1", "", "  \n#\n"} {
+		f, err := Parse([]byte(content))
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", content, err)
+		}
+		if got := string(f.Bytes()); got != content {
+			t.Errorf("Round trip changed the file:\n%q\nwant:\n%q", got, content)
+		}
+	}
+}
+
+func TestSetKeepsLayout(t *testing.T) {
+	f, _ := Parse([]byte(sample))
+	f.Set("APP_ENV", "production")
+	f.Set("APP_NAME", "Shop")
+	f.Set("APP_KEY", "base64:xyz=")
+	f.Set("NEW_KEY", "value")
+
+	got := string(f.Bytes())
+	want := strings.NewReplacer(
+		`APP_ENV=local # the environment`, `APP_ENV=production # the environment`,
+		`APP_NAME="My App"`, `APP_NAME="Shop"`,
+		`export APP_KEY='base64:abc='`, `export APP_KEY='base64:xyz='`,
+	).Replace(sample) + "NEW_KEY=value\n"
+	if got != want {
+		t.Errorf("Unexpected file after Set:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSetAfter(t *testing.T) {
+	f, _ := Parse([]byte("APP_KEY=new\nAPP_URL=http://localhost\n"))
+	f.SetAfter("APP_PREVIOUS_KEYS", "old", "APP_KEY")
+	f.SetAfter("APP_DEBUG", "true", "MISSING")
+	f.SetAfter("APP_PREVIOUS_KEYS", "older,old", "APP_URL")
+
+	want := "APP_KEY=new\nAPP_PREVIOUS_KEYS=older,old\nAPP_URL=http://localhost\nAPP_DEBUG=true\n"
+	if got := string(f.Bytes()); got != want {
+		t.Errorf("Unexpected file after SetAfter:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSetQuotesWhenNeeded(t *testing.T) {
+	testCases := []struct {
+		value string
+		want  string
+	}{
+		{"plain", "K=plain"},
+		{"base64:abc=", "K=base64:abc="},
+		{"My App", `K="My App"`},
+		{"http://localhost#anchor", "K=http://localhost#anchor"},
+		{"a #b", `K="a #b"`},
+		{"pa$$word", `K='pa$$word'`},
+		{`it's $5`, `K="it's \$5"`},
+		{"line1\nline2", "K=\"line1\nline2\""},
+		{"", "K="},
+	}
+
+	for _, tc := range testCases {
+		f, _ := Parse(nil)
+		f.Set("K", tc.value)
+		got := strings.TrimSuffix(string(f.Bytes()), "\n")
+		if got != tc.want {
+			t.Errorf("Set(%q) wrote %s; want %s", tc.value, got, tc.want)
+		}
+
+		reparsed, err := Parse(f.Bytes())
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", got, err)
+		}
+		if value, _ := reparsed.Get("K"); value != tc.value {
+			t.Errorf("Value %q did not round-trip, got %q", tc.value, value)
+		}
+	}
+}
+
+func TestSetSwitchesQuotesWhenRequired(t *testing.T) {
+	f, _ := Parse([]byte("A=plain\nB='single'\n"))
+	f.Set("A", "two words")
+	f.Set("B", "it's")
+
+	if got, want := string(f.Bytes()), "A=\"two words\"\nB=\"it's\"\n"; got != want {
+		t.Errorf("Unexpected file:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestCommentAndUncomment(t *testing.T) {
+	f, _ := Parse([]byte(sample))
+
+	if !f.Uncomment("DB_HOST") || !f.Uncomment("DB_PORT") {
+		t.Fatal("Expected the commented out keys to be uncommented")
+	}
+	if f.Uncomment("DB_HOST") {
+		t.Error("Expected an active key not to be uncommented again")
+	}
+	if value, _ := f.Get("DB_PORT"); value != "3306" {
+		t.Errorf("Expected DB_PORT to keep its value, got %q", value)
+	}
+	got := string(f.Bytes())
+	if !strings.Contains(got, "\nDB_HOST=127.0.0.1\nDB_PORT=3306\n") {
+		t.Errorf("Expected the keys to be uncommented in place:\n%s", got)
+	}
+
+	f.Set("DB_PORT", "5432")
+	f.Comment("DB_HOST")
+	f.Comment("DB_PORT")
+	got = string(f.Bytes())
+	if !strings.Contains(got, "\n# DB_HOST=127.0.0.1\n# DB_PORT=5432\n") {
+		t.Errorf("Expected the keys to be commented out in place:\n%s", got)
+	}
+}
+
+func TestUnset(t *testing.T) {
+	f, _ := Parse([]byte("A=1\nB=2\n# B=3\nA=4\n"))
+	if !f.Unset("A") || f.Unset("MISSING") {
+		t.Error("Unexpected Unset result")
+	}
+	if got := string(f.Bytes()); got != "B=2\n# B=3\n" {
+		t.Errorf("Unexpected file after Unset: %q", got)
+	}
+}
+
+func TestCRLFIsKept(t *testing.T) {
+	f, _ := Parse([]byte("A=1\r\nB=2\r\n"))
+	f.Set("B", "two words")
+	f.Set("C", "3")
+
+	if got, want := string(f.Bytes()), "A=1\r\nB=\"two words\"\r\nC=3\r\n"; got != want {
+		t.Errorf("Unexpected line endings: %q; want %q", got, want)
+	}
+}
+
+func TestExportAndWhitespace(t *testing.T) {
+	f, err := Parse([]byte("export   A = spaced  \n  B=indented\nC=\"x\" # note\nD= # only a comment\n"))
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	for key, want := range map[string]string{"A": "spaced", "B": "indented", "C": "x", "D": ""} {
+		if got, _ := f.Get(key); got != want {
+			t.Errorf("Get(%s) = %q; want %q", key, got, want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	testCases := []struct {
+		data string
+		want string
+	}{
+		{"A=1\nB=\"unterminated\nC=3\n", "line 2: unterminated quoted value for B"},
+		{"A=1\nnot an assignment\n", "line 2: expected KEY=value"},
+		{"1A=1\n", "line 1: invalid key"},
+		{"A='x' trailing\n", "line 1: unexpected characters"},
+	}
+
+	for _, tc := range testCases {
+		_, err := Parse([]byte(tc.data))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q) error = %v; want %q", tc.data, err, tc.want)
+		}
+	}
+}
+
+func TestSaveKeepsPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("File modes are not supported on Windows")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	os.WriteFile(path, []byte("A=1\n"), 0600)
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned error: %v", err)
+	}
+	f.Set("A", "2")
+	if err := f.Save(path); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected mode 0600 to be kept, got %o", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected no temporary files to be left behind, got %d entries", len(entries))
+	}
+
+	// Writing through a symlink replaces its target
+	link := filepath.Join(dir, "link.env")
+	os.Symlink(path, link)
+	if err := WriteFile(link, []byte("A=3\n")); err != nil {
+		t.Fatalf("WriteFile returned error: %v", err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Expected the symlink to be kept")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "A=3\n" {
+		t.Errorf("Expected the symlink target to be updated, got %q", data)
+	}
+}
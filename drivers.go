@@ -0,0 +1,533 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"laravel-cli/dotenv"
+)
+
+// databaseDriver describes a database a new application can use: how it is
+// presented, which .env keys hold its connection, and what the application
+// needs to talk to it.
+//
+// Teams register extra drivers, or adjust a built-in one, with a table in a
+// config file. Fields that are left out keep their built-in values:
+//
+//	[drivers.cockroach]
+//	name = "CockroachDB"
+//	protocol = "pgsql"
+//	port = 26257
+//	packages = ["ylsideas/cockroachdb-laravel"]
+//	extensions = ["pdo_pgsql"]
+//	env = ["DB_SSLMODE=require"]
+//	driver = "pgsql"
+//
+//	[drivers.cockroach.keys]
+//	host = "COCKROACH_HOST"
+//
+// A new driver needs a driver setting: it is the Laravel driver of the
+// connection written to config/database.php, which reads the driver's keys.
+type databaseDriver struct {
+	Name        string // The DB_CONNECTION value
+	DisplayName string
+	Source      string // "built-in" or the config file that registered or changed it
+
+	// Protocol is the server the driver talks to: mysql, pgsql, sqlsrv or
+	// mongodb. It decides how connectivity is checked and how databases are
+	// created. File-based drivers have none.
+	Protocol string
+	Port     int // Zero for file-based drivers
+
+	Keys       driverEnvKeys
+	Env        []envChange // Extra keys written to .env when the driver is chosen
+	Packages   []string    // Composer packages the application needs
+	Extensions []string    // PHP extensions the application needs
+	URLSchemes []string    // Schemes accepted by --database-url
+	NameLimit  int         // Maximum database name length in bytes
+	StartHint  string      // How to get the server running
+
+	// Connection is added to the connections of config/database.php when the
+	// skeleton does not define one for the driver.
+	Connection string
+
+	// LaravelDriver is the driver of the connection generated for a driver
+	// from a config file. Built-in drivers spell their Connection out.
+	LaravelDriver string
+}
+
+// driverEnvKeys are the .env keys a driver reads its connection from. Empty
+// keys are not used by the driver.
+type driverEnvKeys struct {
+	URL, Host, Port, Socket, Database, Username, Password string
+}
+
+// sqlEnvKeys are the keys of the connections in Laravel's config/database.php.
+var sqlEnvKeys = driverEnvKeys{
+	URL: "DB_URL", Host: "DB_HOST", Port: "DB_PORT", Socket: "DB_SOCKET",
+	Database: "DB_DATABASE", Username: "DB_USERNAME", Password: "DB_PASSWORD",
+}
+
+// hasServer reports whether the driver connects to a database server.
+func (d *databaseDriver) hasServer() bool {
+	return d.Port > 0
+}
+
+// canCreateDatabases reports whether databaseScript supports the driver.
+func (d *databaseDriver) canCreateDatabases() bool {
+	return d.Protocol == "mysql" || d.Protocol == "pgsql" || d.Protocol == "sqlsrv"
+}
+
+// builtinDrivers are available without any configuration, in the order of
+// the database prompt.
+var builtinDrivers = []*databaseDriver{
+	{
+		Name:        "sqlite",
+		DisplayName: "SQLite",
+		Keys:        sqlEnvKeys,
+		Extensions:  []string{"pdo_sqlite"},
+		URLSchemes:  []string{"sqlite"},
+	},
+	{
+		Name:        "mysql",
+		DisplayName: "MySQL",
+		Protocol:    "mysql",
+		Port:        3306,
+		Keys:        sqlEnvKeys,
+		Extensions:  []string{"pdo_mysql"},
+		URLSchemes:  []string{"mysql"},
+		NameLimit:   64,
+		StartHint:   "Start MySQL, for example with `brew services start mysql`, `sudo systemctl start mysql` or `docker run -d -p 3306:3306 -e MYSQL_ALLOW_EMPTY_PASSWORD=yes mysql`",
+	},
+	{
+		Name:        "mariadb",
+		DisplayName: "MariaDB",
+		Protocol:    "mysql",
+		Port:        3306,
+		Keys:        sqlEnvKeys,
+		Extensions:  []string{"pdo_mysql"},
+		URLSchemes:  []string{"mariadb"},
+		NameLimit:   64,
+		StartHint:   "Start MariaDB, for example with `brew services start mariadb`, `sudo systemctl start mariadb` or `docker run -d -p 3306:3306 -e MARIADB_ALLOW_EMPTY_ROOT_PASSWORD=yes mariadb`",
+	},
+	{
+		Name:        "pgsql",
+		DisplayName: "PostgreSQL",
+		Protocol:    "pgsql",
+		Port:        5432,
+		Keys:        sqlEnvKeys,
+		Extensions:  []string{"pdo_pgsql"},
+		URLSchemes:  []string{"pgsql", "postgres", "postgresql"},
+		NameLimit:   63,
+		StartHint:   "Start PostgreSQL, for example with `brew services start postgresql`, `sudo systemctl start postgresql` or `docker run -d -p 5432:5432 -e POSTGRES_HOST_AUTH_METHOD=trust postgres`",
+	},
+	{
+		Name:        "sqlsrv",
+		DisplayName: "SQL Server",
+		Protocol:    "sqlsrv",
+		Port:        1433,
+		Keys:        sqlEnvKeys,
+		Extensions:  []string{"pdo_sqlsrv"},
+		URLSchemes:  []string{"sqlsrv", "sqlserver", "mssql"},
+		NameLimit:   128,
+		StartHint:   "Start SQL Server, for example with `docker run -d -p 1433:1433 -e ACCEPT_EULA=Y -e MSSQL_SA_PASSWORD=<password> mcr.microsoft.com/mssql/server`, and make sure TCP/IP connections are enabled",
+	},
+	{
+		Name:        "singlestore",
+		DisplayName: "SingleStore",
+		Protocol:    "mysql",
+		Port:        3306,
+		Keys:        sqlEnvKeys,
+		Packages:    []string{"singlestoredb/singlestoredb-laravel"},
+		Extensions:  []string{"pdo_mysql"},
+		URLSchemes:  []string{"singlestore"},
+		NameLimit:   64,
+		StartHint:   "Start SingleStore, for example with `docker run -d -p 3306:3306 -e ROOT_PASSWORD=<password> ghcr.io/singlestore-labs/singlestoredb-dev`",
+		Connection: `        'singlestore' => [
+            'driver' => 'singlestore',
+            'url' => env('DB_URL'),
+            'host' => env('DB_HOST', '127.0.0.1'),
+            'port' => env('DB_PORT', '3306'),
+            'database' => env('DB_DATABASE', 'laravel'),
+            'username' => env('DB_USERNAME', 'root'),
+            'password' => env('DB_PASSWORD', ''),
+            'unix_socket' => env('DB_SOCKET', ''),
+            'charset' => env('DB_CHARSET', 'utf8mb4'),
+            'collation' => env('DB_COLLATION', 'utf8mb4_unicode_ci'),
+            'prefix' => '',
+            'prefix_indexes' => true,
+            'strict' => true,
+            'engine' => null,
+            'options' => extension_loaded('pdo_mysql') ? array_filter([
+                PDO::MYSQL_ATTR_SSL_CA => env('MYSQL_ATTR_SSL_CA'),
+            ]) : [],
+        ],
+`,
+	},
+	{
+		Name:        "mongodb",
+		DisplayName: "MongoDB",
+		Protocol:    "mongodb",
+		Port:        27017,
+		Keys:        driverEnvKeys{URL: "MONGODB_URI", Database: "MONGODB_DATABASE"},
+		Env: []envChange{
+			{Action: "set", Key: "MONGODB_URI", Value: "mongodb://127.0.0.1:27017"},
+			{Action: "set", Key: "MONGODB_DATABASE", Value: "laravel"},
+		},
+		Packages:   []string{"mongodb/laravel-mongodb"},
+		Extensions: []string{"mongodb"},
+		URLSchemes: []string{"mongodb", "mongodb+srv"},
+		NameLimit:  63,
+		StartHint:  "Start MongoDB, for example with `brew services start mongodb-community`, `sudo systemctl start mongod` or `docker run -d -p 27017:27017 mongo`",
+		Connection: `        'mongodb' => [
+            'driver' => 'mongodb',
+            'dsn' => env('MONGODB_URI', 'mongodb://127.0.0.1:27017'),
+            'database' => env('MONGODB_DATABASE', 'laravel'),
+        ],
+`,
+	},
+}
+
+// registeredDrivers are the built-in drivers followed by the drivers from the
+// config files, once they have been read.
+var registeredDrivers = copyDrivers(builtinDrivers)
+
+var driverNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// driverProtocols are the values accepted for protocol in a driver table.
+var driverProtocols = []string{"mysql", "pgsql", "sqlsrv", "mongodb"}
+
+// driverKeyNames are the settings of the keys table of a driver, and the
+// fields of driverEnvKeys they set.
+var driverKeyNames = []string{"url", "host", "port", "socket", "database", "username", "password"}
+
+func (k *driverEnvKeys) field(name string) *string {
+	switch name {
+	case "url":
+		return &k.URL
+	case "host":
+		return &k.Host
+	case "port":
+		return &k.Port
+	case "socket":
+		return &k.Socket
+	case "database":
+		return &k.Database
+	case "username":
+		return &k.Username
+	case "password":
+		return &k.Password
+	}
+	return nil
+}
+
+func copyDrivers(drivers []*databaseDriver) []*databaseDriver {
+	copies := make([]*databaseDriver, len(drivers))
+	for i, d := range drivers {
+		driver := *d
+		if driver.Source == "" {
+			driver.Source = "built-in"
+		}
+		copies[i] = &driver
+	}
+	return copies
+}
+
+// lookupDriver returns the registered driver with the given name, or nil.
+func lookupDriver(name string) *databaseDriver {
+	for _, d := range registeredDrivers {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// driverNames lists the registered drivers in prompt order.
+func driverNames() []string {
+	names := make([]string, len(registeredDrivers))
+	for i, d := range registeredDrivers {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// validateDriverName checks that name is a registered driver.
+func validateDriverName(name string) error {
+	if lookupDriver(name) == nil {
+		return fmt.Errorf("possible values are: %s", strings.Join(driverNames(), ", "))
+	}
+	return nil
+}
+
+// registerConfigDrivers rebuilds the registry from the built-in drivers and
+// the [drivers.<name>] tables of the config files, in order of increasing
+// priority.
+func registerConfigDrivers(docs []*confDoc) error {
+	drivers := copyDrivers(builtinDrivers)
+	created := map[string]*confError{} // Where each new driver was defined
+	for _, doc := range docs {
+		for _, name := range doc.subKeys("drivers") {
+			if !driverNamePattern.MatchString(name) {
+				entry, _ := doc.firstWithPrefix("drivers." + name + ".")
+				return &confError{Path: doc.Path, Line: entry.Line, Msg: fmt.Sprintf("invalid driver name %q: use lowercase letters, digits, - and _", name)}
+			}
+			var driver *databaseDriver
+			for _, d := range drivers {
+				if d.Name == name {
+					driver = d
+				}
+			}
+			if driver == nil {
+				driver = &databaseDriver{Name: name, DisplayName: name, Keys: sqlEnvKeys}
+				drivers = append(drivers, driver)
+				entry, _ := doc.firstWithPrefix("drivers." + name + ".")
+				created[name] = &confError{Path: doc.Path, Line: entry.Line}
+			}
+			if err := applyDriverTable(doc, driver); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, driver := range drivers {
+		if driver.LaravelDriver != "" {
+			driver.Connection = driverConnection(driver)
+		} else if err := created[driver.Name]; err != nil {
+			// DB_CONNECTION would name a connection the application lacks
+			err.Msg = fmt.Sprintf("driver %q needs a driver setting, the Laravel driver of its connection (mysql, pgsql, ...)", driver.Name)
+			return err
+		}
+	}
+	registeredDrivers = drivers
+	return nil
+}
+
+// driverConnection renders the config/database.php connection of a driver
+// from a config file, reading the driver's keys.
+func driverConnection(driver *databaseDriver) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "        '%s' => [\n", driver.Name)
+	fmt.Fprintf(&b, "            'driver' => '%s',\n", driver.LaravelDriver)
+	settings := []struct{ name, key, value string }{
+		{"url", driver.Keys.URL, ""},
+		{"host", driver.Keys.Host, "127.0.0.1"},
+		{"port", driver.Keys.Port, ""},
+		{"unix_socket", driver.Keys.Socket, ""},
+		{"database", driver.Keys.Database, "laravel"},
+		{"username", driver.Keys.Username, "root"},
+		{"password", driver.Keys.Password, ""},
+	}
+	if driver.Port > 0 {
+		settings[2].value = strconv.Itoa(driver.Port)
+	}
+	if driver.LaravelDriver == "mongodb" {
+		settings[0].name = "dsn"
+	}
+	for _, setting := range settings {
+		switch {
+		case setting.key == "":
+		case setting.name == "url" || setting.name == "dsn":
+			fmt.Fprintf(&b, "            '%s' => env('%s'),\n", setting.name, setting.key)
+		default:
+			fmt.Fprintf(&b, "            '%s' => env('%s', '%s'),\n", setting.name, setting.key, setting.value)
+		}
+	}
+	b.WriteString("        ],\n")
+	return b.String()
+}
+
+// applyDriverTable sets the fields of driver given in its config table.
+func applyDriverTable(doc *confDoc, driver *databaseDriver) error {
+	prefix := "drivers." + driver.Name + "."
+	portSet := false
+	driver.Source = doc.Path
+
+	for _, entry := range doc.Entries {
+		if !strings.HasPrefix(entry.Key, prefix) {
+			continue
+		}
+		key := strings.TrimPrefix(entry.Key, prefix)
+		fail := func(format string, args ...interface{}) error {
+			return &confError{Path: doc.Path, Line: entry.Line, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		keyName, isKey := strings.CutPrefix(key, "keys.")
+		if isKey && contains(driverKeyNames, keyName) {
+			if entry.IsList {
+				return fail("%s expects a single value", key)
+			}
+			// An empty key leaves the setting out of the connection
+			if entry.Value != "" && !dotenv.ValidKey(entry.Value) {
+				return fail("invalid %s %q: expected an environment variable name", key, entry.Value)
+			}
+			*driver.Keys.field(keyName) = entry.Value
+			continue
+		}
+
+		switch key {
+		case "name", "protocol", "port", "start-hint", "driver":
+			if entry.IsList {
+				return fail("%s expects a single value", key)
+			}
+		case "packages", "extensions", "env", "url-schemes":
+			if !entry.IsList {
+				return fail("%s expects a list", key)
+			}
+		default:
+			return fail("unknown driver setting %q", key)
+		}
+
+		switch key {
+		case "name":
+			driver.DisplayName = entry.Value
+		case "protocol":
+			if !contains(driverProtocols, entry.Value) {
+				return fail("invalid protocol %q: possible values are: %s", entry.Value, strings.Join(driverProtocols, ", "))
+			}
+			driver.Protocol = entry.Value
+		case "port":
+			port, err := strconv.Atoi(entry.Value)
+			if err != nil || port < 0 || port > 65535 {
+				return fail("invalid port %q", entry.Value)
+			}
+			driver.Port, portSet = port, true
+		case "start-hint":
+			driver.StartHint = entry.Value
+		case "driver":
+			if !driverNamePattern.MatchString(entry.Value) {
+				return fail("invalid driver %q", entry.Value)
+			}
+			driver.LaravelDriver = entry.Value
+		case "packages":
+			driver.Packages = entry.List
+		case "extensions":
+			driver.Extensions = entry.List
+		case "url-schemes":
+			driver.URLSchemes = entry.List
+		case "env":
+			driver.Env = nil
+			for _, pair := range entry.List {
+				envKey, value, ok := strings.Cut(pair, "=")
+				if !ok || !dotenv.ValidKey(envKey) {
+					return fail("invalid env entry %q: expected KEY=value", pair)
+				}
+				driver.Env = append(driver.Env, envChange{Action: "set", Key: envKey, Value: value})
+			}
+		}
+	}
+
+	// A new driver speaking a known protocol listens on its usual port
+	if !portSet && driver.Port == 0 && driver.Protocol != "" {
+		for _, builtin := range builtinDrivers {
+			if builtin.Protocol == driver.Protocol {
+				driver.Port = builtin.Port
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// addDatabaseConnection adds the driver's connection to config/database.php
+// unless the file already defines it.
+func addDatabaseConnection(projectDir string, driver *databaseDriver) error {
+	if driver.Connection == "" {
+		return nil
+	}
+	path := filepath.Join(projectDir, "config", "database.php")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	config := string(data)
+	if strings.Contains(config, "'"+driver.Name+"' => [") {
+		return nil
+	}
+
+	marker := "'connections' => ["
+	i := strings.Index(config, marker)
+	if i < 0 {
+		return fmt.Errorf("%s has no connections array", path)
+	}
+	lineEnd := strings.Index(config[i:], "\n")
+	if lineEnd < 0 {
+		return fmt.Errorf("%s has no connections array", path)
+	}
+	at := i + lineEnd + 1
+	config = config[:at] + "\n" + driver.Connection + config[at:]
+	return dotenv.WriteFile(path, []byte(config))
+}
+
+// setupDatabaseDriver gets the application ready for the driver: it warns
+// about missing PHP extensions, installs the driver's packages and adds its
+// connection to config/database.php.
+func setupDatabaseDriver(ctx context.Context, projectDir string, driver *databaseDriver) error {
+	if missing := missingExtensions(ctx, projectDir, driver.Extensions); len(missing) > 0 {
+		printWarning(ctx, fmt.Sprintf("%s needs the PHP extensions %s, which are not installed",
+			driver.DisplayName, strings.Join(missing, ", ")))
+	}
+
+	if len(driver.Packages) > 0 && offline {
+		printWarning(ctx, fmt.Sprintf("--offline does not download packages; run composer require %s once online", strings.Join(driver.Packages, " ")))
+	} else if len(driver.Packages) > 0 {
+		if !quiet {
+			fmt.Printf("Installing the %s packages...\n", driver.DisplayName)
+		}
+		cmd := newCommand(projectDir, "composer", append([]string{"require"}, driver.Packages...)...)
+		if _, err := runCommand(ctx, cmd); err != nil {
+			if err := warn(ctx, exitPostInstall, err, fmt.Sprintf("Could not install the %s packages", driver.DisplayName)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := addDatabaseConnection(projectDir, driver); err != nil {
+		return warn(ctx, exitPostInstall, err, fmt.Sprintf("Could not add the %s connection to config/database.php", driver.DisplayName))
+	}
+	return nil
+}
+
+// missingExtensions returns the extensions `php -m` does not list. Nothing is
+// reported when PHP cannot be asked.
+func missingExtensions(ctx context.Context, projectDir string, extensions []string) []string {
+	if len(extensions) == 0 {
+		return nil
+	}
+	return unloadedExtensions(phpExtensions(ctx, projectDir), extensions)
+}
+
+// phpExtensions returns the lowercased names of the extensions listed by
+// php -m, or nil when PHP could not be asked.
+func phpExtensions(ctx context.Context, dir string) map[string]bool {
+	result, err := runCommand(ctx, Command{Name: "php", Args: []string{"-m"}, Dir: dir})
+	if err != nil || len(strings.TrimSpace(string(result.Stdout))) == 0 {
+		return nil
+	}
+
+	loaded := map[string]bool{}
+	for _, line := range strings.Split(string(result.Stdout), "\n") {
+		loaded[strings.ToLower(strings.TrimSpace(line))] = true
+	}
+	return loaded
+}
+
+// unloadedExtensions returns the extensions missing from loaded. Nothing is
+// reported when the loaded extensions are unknown.
+func unloadedExtensions(loaded map[string]bool, extensions []string) []string {
+	if loaded == nil {
+		return nil
+	}
+	var missing []string
+	for _, extension := range extensions {
+		if !loaded[strings.ToLower(extension)] {
+			missing = append(missing, extension)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useDriverRegistry restores the built-in drivers after the test.
+func useDriverRegistry(t *testing.T) {
+	t.Helper()
+	t.Cleanup(func() { registeredDrivers = copyDrivers(builtinDrivers) })
+}
+
+func TestConfigDrivers(t *testing.T) {
+	userDir, projectDir := useConfigDirs(t)
+	useDriverRegistry(t)
+	writeTestFile(filepath.Join(userDir, "config.toml"), `database = "cockroach"
+
+[drivers.cockroach]
+name = "CockroachDB"
+protocol = "pgsql"
+packages = ["ylsideas/cockroachdb-laravel"]
+env = ["DB_SSLMODE=require"]
+driver = "pgsql"
+
+[drivers.cockroach.keys]
+host = "COCKROACH_HOST"
+socket = ""
+
+[drivers.mongodb]
+port = 27018
+`)
+	writeTestFile(filepath.Join(projectDir, ".laravel", "config.yaml"), "drivers:\n  mongodb:\n    extensions: [mongodb, intl]\n")
+
+	flags, values, _ := testNewFlags()
+	if err := applyConfigDefaults(flags); err != nil {
+		t.Fatalf("applyConfigDefaults returned error: %v", err)
+	}
+	if *values["database"] != "cockroach" {
+		t.Errorf("Expected a config driver to be a valid database, got %q", *values["database"])
+	}
+
+	cockroach := lookupDriver("cockroach")
+	if cockroach == nil {
+		t.Fatal("Expected the cockroach driver to be registered")
+	}
+	if cockroach.DisplayName != "CockroachDB" || cockroach.Port != 5432 || cockroach.Packages[0] != "ylsideas/cockroachdb-laravel" {
+		t.Errorf("Unexpected driver: %+v", cockroach)
+	}
+	if cockroach.Keys.Host != "COCKROACH_HOST" || cockroach.Keys.Socket != "" || cockroach.Keys.Database != "DB_DATABASE" {
+		t.Errorf("Expected only the given keys to change, got %+v", cockroach.Keys)
+	}
+
+	// The connection DB_CONNECTION=cockroach refers to is added
+	config := filepath.Join(projectDir, "config", "database.php")
+	os.MkdirAll(filepath.Dir(config), 0755)
+	writeTestFile(config, "<?php\n\nreturn [\n    'connections' => [\n    ],\n];\n")
+	if err := addDatabaseConnection(projectDir, cockroach); err != nil {
+		t.Fatalf("addDatabaseConnection returned error: %v", err)
+	}
+	php, _ := readTestFile(config)
+	for _, want := range []string{
+		"        'cockroach' => [\n            'driver' => 'pgsql',\n            'url' => env('DB_URL'),\n",
+		"            'host' => env('COCKROACH_HOST', '127.0.0.1'),\n            'port' => env('DB_PORT', '5432'),\n            'database' => env('DB_DATABASE', 'laravel'),\n",
+	} {
+		if !strings.Contains(php, want) {
+			t.Errorf("Expected config/database.php to contain %q, got:\n%s", want, php)
+		}
+	}
+	if strings.Contains(php, "unix_socket") {
+		t.Errorf("Expected the emptied socket key to be left out, got:\n%s", php)
+	}
+	if len(cockroach.Env) != 1 || cockroach.Env[0].Key != "DB_SSLMODE" || cockroach.Env[0].Value != "require" {
+		t.Errorf("Expected the env template to be read, got %+v", cockroach.Env)
+	}
+	if names := driverNames(); names[len(names)-1] != "cockroach" {
+		t.Errorf("Expected config drivers after the built-in ones, got %v", names)
+	}
+
+	mongodb := lookupDriver("mongodb")
+	if mongodb.Port != 27018 || strings.Join(mongodb.Extensions, ",") != "mongodb,intl" || mongodb.Packages[0] != "mongodb/laravel-mongodb" {
+		t.Errorf("Expected the config to override only the given fields, got %+v", mongodb)
+	}
+	if builtin := builtinDrivers[len(builtinDrivers)-1]; builtin.Port != 27017 {
+		t.Error("Expected the built-in driver to be left alone")
+	}
+}
+
+func TestConfigDriverErrors(t *testing.T) {
+	testCases := map[string]string{
+		"[drivers.Mongo]\nport = 1\n":                "config.toml:2: invalid driver name \"Mongo\"",
+		"[drivers.mongodb]\nprot = 1\n":              "config.toml:2: unknown driver setting \"prot\"",
+		"[drivers.mongodb]\npackages = \"a/b\"\n":    "config.toml:2: packages expects a list",
+		"[drivers.redshift]\nprotocol = \"odbc\"\n":  "config.toml:2: invalid protocol \"odbc\"",
+		"[drivers.mysql]\nport = 70000\n":            "config.toml:2: invalid port \"70000\"",
+		"[drivers.mysql]\nenv = [\"not a pair\"]\n":  "config.toml:2: invalid env entry \"not a pair\"",
+		"[drivers.redshift]\nport = 5439\n":          "config.toml:2: driver \"redshift\" needs a driver setting",
+		"[drivers.mysql.keys]\nhost = \"db host\"\n": "config.toml:2: invalid keys.host \"db host\"",
+		"[drivers.mysql.keys]\nschema = \"X\"\n":     "config.toml:2: unknown driver setting \"keys.schema\"",
+	}
+	for data, want := range testCases {
+		userDir, _ := useConfigDirs(t)
+		useDriverRegistry(t)
+		writeTestFile(filepath.Join(userDir, "config.toml"), data)
+
+		flags, _, _ := testNewFlags()
+		err := applyConfigDefaults(flags)
+		if exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), want) {
+			t.Errorf("applyConfigDefaults(%q) error = %v; want %q", data, err, want)
+		}
+	}
+}
+
+func TestSelectDatabaseListsRegisteredDrivers(t *testing.T) {
+	useFakeRunner(t, "7\n")
+	var out strings.Builder
+	promptOut = &out
+
+	if got := selectDatabase(newPrompter(nil)); got != "mongodb" {
+		t.Errorf("selectDatabase() = %q; want mongodb", got)
+	}
+	for _, line := range []string{"1) SQLite\n", "6) SingleStore\n", "7) MongoDB\n"} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected the prompt to list %q, got:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestMongoDBDriver(t *testing.T) {
+	// Default host, port and database name, no migrations, no npm
+	fake := useFakeRunner(t, "\n\n\nn\nn\n")
+	quiet, database = true, "mongodb"
+	fake.handler = func(cmd Command) (*Result, error) {
+		if cmd.Name == "composer" && len(cmd.Args) > 2 && cmd.Args[0] == "create-project" {
+			fakeSkeletonHandler(cmd)
+			dir := filepath.Join(cmd.Dir, cmd.Args[2], "config")
+			os.MkdirAll(dir, 0755)
+			os.WriteFile(filepath.Join(dir, "database.php"), []byte("<?php\n\nreturn [\n    'connections' => [\n\n        'sqlite' => [\n        ],\n    ],\n];\n"), 0644)
+		}
+		return &Result{}, nil
+	}
+
+	if err := createNewProject("shop-api"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	env, _ := os.ReadFile(filepath.Join("shop-api", ".env"))
+	for _, line := range []string{"DB_CONNECTION=mongodb\n", "MONGODB_URI=mongodb://127.0.0.1:27017\n", "MONGODB_DATABASE=shop_api\n", "# DB_HOST="} {
+		if !strings.Contains(string(env), line) {
+			t.Errorf("Expected .env to contain %q, got:\n%s", line, env)
+		}
+	}
+	if !contains(fake.commandLines(), "composer require mongodb/laravel-mongodb") {
+		t.Errorf("Expected the MongoDB package to be installed, got:\n%s", strings.Join(fake.commandLines(), "\n"))
+	}
+	config, _ := os.ReadFile(filepath.Join("shop-api", "config", "database.php"))
+	if !strings.Contains(string(config), "'dsn' => env('MONGODB_URI'") {
+		t.Errorf("Expected the mongodb connection to be added, got:\n%s", config)
+	}
+
+	// The connection is only added once
+	if err := addDatabaseConnection("shop-api", lookupDriver("mongodb")); err != nil {
+		t.Fatalf("addDatabaseConnection returned error: %v", err)
+	}
+	again, _ := os.ReadFile(filepath.Join("shop-api", "config", "database.php"))
+	if string(again) != string(config) {
+		t.Error("Expected an existing connection to be kept")
+	}
+}
+
+func TestMissingExtensions(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	fake.handler = func(cmd Command) (*Result, error) {
+		return &Result{Stdout: []byte("[PHP Modules]\nCore\nPDO\npdo_mysql\n\n[Zend Modules]\n")}, nil
+	}
+
+	missing := missingExtensions(context.Background(), ".", []string{"PDO_MYSQL", "mongodb"})
+	if len(missing) != 1 || missing[0] != "mongodb" {
+		t.Errorf("missingExtensions() = %v; want [mongodb]", missing)
+	}
+
+	fake.handler = fakeSkeletonHandler
+	if missing := missingExtensions(context.Background(), ".", []string{"mongodb"}); len(missing) != 0 {
+		t.Errorf("Expected nothing to be reported when PHP lists no modules, got %v", missing)
+	}
+}
@@ -0,0 +1,261 @@
+// Package encryption encrypts and decrypts strings the way Laravel's
+// Illuminate\Encryption\Encrypter does, so payloads can be exchanged with
+// `php artisan env:encrypt` and `php artisan env:decrypt`.
+//
+// A payload is the base64 encoding of a JSON object:
+//
+//	{"iv":"...","value":"...","mac":"...","tag":"..."}
+//
+// iv and value are base64 encoded, value being the encrypted PHP-serialized
+// string. CBC ciphers authenticate the payload with mac, an HMAC-SHA256 of
+// iv and value in hex; GCM ciphers use tag and leave mac empty.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultCipher is the cipher Laravel uses unless configured otherwise.
+const DefaultCipher = "AES-256-CBC"
+
+// Ciphers lists the supported ciphers with their key sizes in bytes.
+var Ciphers = map[string]int{
+	"AES-128-CBC": 16,
+	"AES-256-CBC": 32,
+	"AES-128-GCM": 16,
+	"AES-256-GCM": 32,
+}
+
+// randReader provides keys and IVs; tests replace it to get fixed output.
+var randReader io.Reader = rand.Reader
+
+// ErrInvalidPayload is returned for payloads that are malformed or fail
+// authentication, which usually means the key is wrong.
+var ErrInvalidPayload = errors.New("the payload is invalid")
+
+// Encrypter encrypts and decrypts strings with a single key.
+type Encrypter struct {
+	key    []byte
+	cipher string
+}
+
+type payload struct {
+	IV    string `json:"iv"`
+	Value string `json:"value"`
+	MAC   string `json:"mac"`
+	Tag   string `json:"tag"`
+}
+
+// New returns an Encrypter for cipher, which is matched case-insensitively.
+// The key must have the length the cipher requires.
+func New(key []byte, cipherName string) (*Encrypter, error) {
+	cipherName = strings.ToUpper(cipherName)
+	size, ok := Ciphers[cipherName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cipher %q", cipherName)
+	}
+	if len(key) != size {
+		return nil, fmt.Errorf("%s requires a %d byte key, got %d bytes", cipherName, size, len(key))
+	}
+	return &Encrypter{key: key, cipher: cipherName}, nil
+}
+
+// GenerateKey returns a random key for cipher.
+func GenerateKey(cipherName string) ([]byte, error) {
+	size, ok := Ciphers[strings.ToUpper(cipherName)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported cipher %q", cipherName)
+	}
+	key := make([]byte, size)
+	if _, err := io.ReadFull(randReader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ParseKey decodes a key as written in APP_KEY: base64 with a "base64:"
+// prefix. Keys without the prefix are used as raw bytes, like Laravel does.
+func ParseKey(key string) ([]byte, error) {
+	if encoded, ok := strings.CutPrefix(key, "base64:"); ok {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("the key is not valid base64: %w", err)
+		}
+		return decoded, nil
+	}
+	return []byte(key), nil
+}
+
+// FormatKey encodes a key the way APP_KEY stores it.
+func FormatKey(key []byte) string {
+	return "base64:" + base64.StdEncoding.EncodeToString(key)
+}
+
+func (e *Encrypter) gcm() bool {
+	return strings.HasSuffix(e.cipher, "-GCM")
+}
+
+// EncryptString serializes value as a PHP string and encrypts it.
+func (e *Encrypter) EncryptString(value string) (string, error) {
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+	plaintext := []byte(serialize(value))
+
+	var p payload
+	if e.gcm() {
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return "", err
+		}
+		iv := make([]byte, aead.NonceSize())
+		if _, err := io.ReadFull(randReader, iv); err != nil {
+			return "", err
+		}
+		sealed := aead.Seal(nil, iv, plaintext, nil)
+		ciphertext, tag := sealed[:len(sealed)-aead.Overhead()], sealed[len(sealed)-aead.Overhead():]
+		p = payload{IV: encode(iv), Value: encode(ciphertext), Tag: encode(tag)}
+	} else {
+		iv := make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(randReader, iv); err != nil {
+			return "", err
+		}
+		ciphertext := pad(plaintext)
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+		p = payload{IV: encode(iv), Value: encode(ciphertext)}
+		p.MAC = e.mac(p.IV, p.Value)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// PHP's json_encode escapes neither HTML characters nor, with
+	// JSON_UNESCAPED_SLASHES, slashes
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(p); err != nil {
+		return "", err
+	}
+	return encode(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// DecryptString decrypts a payload and unserializes the PHP string in it.
+func (e *Encrypter) DecryptString(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encrypted))
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return "", ErrInvalidPayload
+	}
+	iv, err := base64.StdEncoding.DecodeString(p.IV)
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(p.Value)
+	if err != nil {
+		return "", ErrInvalidPayload
+	}
+
+	block, err := aes.NewCipher(e.key)
+	if err != nil {
+		return "", err
+	}
+
+	var plaintext []byte
+	if e.gcm() {
+		tag, err := base64.StdEncoding.DecodeString(p.Tag)
+		if err != nil {
+			return "", ErrInvalidPayload
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return "", err
+		}
+		if len(iv) != aead.NonceSize() || len(tag) != aead.Overhead() {
+			return "", ErrInvalidPayload
+		}
+		if plaintext, err = aead.Open(nil, iv, append(ciphertext, tag...), nil); err != nil {
+			return "", errors.New("the MAC is invalid")
+		}
+	} else {
+		if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return "", ErrInvalidPayload
+		}
+		if !hmac.Equal([]byte(e.mac(p.IV, p.Value)), []byte(p.MAC)) {
+			return "", errors.New("the MAC is invalid")
+		}
+		plaintext = make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+		if plaintext, err = unpad(plaintext); err != nil {
+			return "", err
+		}
+	}
+
+	return unserialize(string(plaintext))
+}
+
+// mac authenticates the base64 encoded iv and value, as hash_hmac does.
+func (e *Encrypter) mac(iv, value string) string {
+	h := hmac.New(sha256.New, e.key)
+	h.Write([]byte(iv + value))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func encode(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// pad applies PKCS#7 padding, which OpenSSL uses for CBC.
+func pad(data []byte) []byte {
+	n := aes.BlockSize - len(data)%aes.BlockSize
+	return append(append([]byte{}, data...), bytes.Repeat([]byte{byte(n)}, n)...)
+}
+
+func unpad(data []byte) ([]byte, error) {
+	n := int(data[len(data)-1])
+	if n == 0 || n > aes.BlockSize || n > len(data) {
+		return nil, errors.New("could not decrypt the data")
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, errors.New("could not decrypt the data")
+		}
+	}
+	return data[:len(data)-n], nil
+}
+
+// serialize encodes a string as PHP's serialize() does: s:<bytes>:"...";
+func serialize(value string) string {
+	return "s:" + strconv.Itoa(len(value)) + `:"` + value + `";`
+}
+
+func unserialize(data string) (string, error) {
+	invalid := errors.New("the decrypted data is not a serialized string")
+	rest, ok := strings.CutPrefix(data, "s:")
+	if !ok {
+		return "", invalid
+	}
+	length, rest, ok := strings.Cut(rest, `:"`)
+	if !ok {
+		return "", invalid
+	}
+	n, err := strconv.Atoi(length)
+	if err != nil || n < 0 || len(rest) != n+2 || !strings.HasSuffix(rest, `";`) {
+		return "", invalid
+	}
+	return rest[:n], nil
+}
@@ -0,0 +1,142 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// The payload below was produced with the openssl command line tool:
+//
+//	printf 's:25:"APP_NAME=Shop\nDB_HOST=db\n";' | openssl enc -aes-256-cbc -K <key> -iv <iv> | base64
+//	printf '<iv><value>' | openssl dgst -sha256 -mac HMAC -macopt hexkey:<key>
+const (
+	testKey     = "base64:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	testIV      = "\xa0\xa1\xa2\xa3\xa4\xa5\xa6\xa7\xa8\xa9\xaa\xab\xac\xad\xae\xaf"
+	testPayload = "eyJpdiI6Im9LR2lvNlNscHFlb3FhcXJySzJ1cnc9PSIsInZhbHVlIjoiVjhzTmF1TEFBdHBzYWFkNU1pUGpXU2plZGlKc0ExbWE0VDlCUEFoSE5mR2N3NVYxaGFJYS9zV3BMcmE4SFIveiIsIm1hYyI6ImFmZjMxZGJjOWM2NzdhYzZkZTljOGVlNTY1M2I0NTc5ZjJlODY3ZmIzZDk1ZGFjNDYxZGM2M2FlNzk4ZWM0MGQiLCJ0YWciOiIifQ=="
+	testEnv     = "APP_NAME=Shop\nDB_HOST=db\n"
+)
+
+func testEncrypter(t *testing.T, cipherName string) *Encrypter {
+	t.Helper()
+	key, err := ParseKey(testKey)
+	if err != nil {
+		t.Fatalf("ParseKey returned error: %v", err)
+	}
+	if Ciphers[strings.ToUpper(cipherName)] == 16 {
+		key = key[:16]
+	}
+	e, err := New(key, cipherName)
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	return e
+}
+
+func TestDecryptKnownPayload(t *testing.T) {
+	got, err := testEncrypter(t, "AES-256-CBC").DecryptString(testPayload + "\n")
+	if err != nil {
+		t.Fatalf("DecryptString returned error: %v", err)
+	}
+	if got != testEnv {
+		t.Errorf("DecryptString() = %q; want %q", got, testEnv)
+	}
+}
+
+func TestEncryptMatchesKnownPayload(t *testing.T) {
+	randReader = bytes.NewReader([]byte(testIV))
+	t.Cleanup(func() { randReader = rand.Reader })
+
+	got, err := testEncrypter(t, "aes-256-cbc").EncryptString(testEnv)
+	if err != nil {
+		t.Fatalf("EncryptString returned error: %v", err)
+	}
+	if got != testPayload {
+		decoded, _ := base64.StdEncoding.DecodeString(got)
+		t.Errorf("Unexpected payload:\n%s", decoded)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for cipherName := range Ciphers {
+		e := testEncrypter(t, cipherName)
+		for _, value := range []string{"", testEnv, "ünïcödé \"quotes\" ; s:3:\"x\";"} {
+			encrypted, err := e.EncryptString(value)
+			if err != nil {
+				t.Fatalf("%s: EncryptString returned error: %v", cipherName, err)
+			}
+			got, err := e.DecryptString(encrypted)
+			if err != nil {
+				t.Fatalf("%s: DecryptString returned error: %v", cipherName, err)
+			}
+			if got != value {
+				t.Errorf("%s: round trip returned %q; want %q", cipherName, got, value)
+			}
+		}
+	}
+}
+
+func TestGCMPayloadHasTagAndNoMAC(t *testing.T) {
+	encrypted, err := testEncrypter(t, "AES-256-GCM").EncryptString(testEnv)
+	if err != nil {
+		t.Fatalf("EncryptString returned error: %v", err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(encrypted)
+	var p payload
+	json.Unmarshal(data, &p)
+
+	iv, _ := base64.StdEncoding.DecodeString(p.IV)
+	tag, _ := base64.StdEncoding.DecodeString(p.Tag)
+	if p.MAC != "" || len(iv) != 12 || len(tag) != 16 {
+		t.Errorf("Unexpected GCM payload: %s", data)
+	}
+}
+
+func TestDecryptRejectsTamperedPayloads(t *testing.T) {
+	e := testEncrypter(t, "AES-256-CBC")
+	data, _ := base64.StdEncoding.DecodeString(testPayload)
+
+	tampered := strings.Replace(string(data), `"mac":"a`, `"mac":"b`, 1)
+	if _, err := e.DecryptString(base64.StdEncoding.EncodeToString([]byte(tampered))); err == nil || !strings.Contains(err.Error(), "MAC is invalid") {
+		t.Errorf("Expected a MAC error, got %v", err)
+	}
+
+	for _, payload := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("[]")), base64.StdEncoding.EncodeToString([]byte(`{"iv":"AAAA","value":"","mac":""}`))} {
+		if _, err := e.DecryptString(payload); err == nil {
+			t.Errorf("Expected DecryptString(%q) to fail", payload)
+		}
+	}
+
+	otherKey, _ := GenerateKey(DefaultCipher)
+	other, _ := New(otherKey, DefaultCipher)
+	if _, err := other.DecryptString(testPayload); err == nil {
+		t.Error("Expected decryption with another key to fail")
+	}
+}
+
+func TestKeys(t *testing.T) {
+	key, err := GenerateKey("AES-128-GCM")
+	if err != nil || len(key) != 16 {
+		t.Fatalf("GenerateKey returned %d bytes, %v", len(key), err)
+	}
+	parsed, err := ParseKey(FormatKey(key))
+	if err != nil || !bytes.Equal(parsed, key) {
+		t.Errorf("Expected the formatted key to parse back, got %v", err)
+	}
+
+	if _, err := New(key, "AES-256-CBC"); err == nil || !strings.Contains(err.Error(), "requires a 32 byte key") {
+		t.Errorf("Expected a key length error, got %v", err)
+	}
+	if _, err := New(key, "DES"); err == nil {
+		t.Error("Expected an unsupported cipher error")
+	}
+	if _, err := ParseKey("base64:%%%"); err == nil {
+		t.Error("Expected an invalid base64 key to fail")
+	}
+	if raw, _ := ParseKey("0123456789abcdef"); string(raw) != "0123456789abcdef" {
+		t.Error("Expected keys without a prefix to be used as is")
+	}
+}
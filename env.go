@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"laravel-cli/dotenv"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	envName   string
+	envFile   string
+	envReveal bool
+)
+
+// secretKeyParts mark a variable as secret when they appear as a word of its
+// name, such as APP_KEY, DB_PASSWORD or STRIPE_SECRET.
+var secretKeyParts = []string{"KEY", "SECRET", "PASSWORD", "PASS", "PASSPHRASE", "TOKEN", "PRIVATE", "CREDENTIALS", "AUTH", "SALT", "DSN"}
+
+const maskedValue = "********"
+
+// findProjectRoot returns the root of the Laravel application containing the
+// current directory, recognized by its artisan script.
+func findProjectRoot() (string, error) {
+	artisan := findInParents("artisan", func(path string) bool {
+		info, err := os.Stat(path)
+		return err == nil && !info.IsDir()
+	})
+	if artisan == "" {
+		return "", exitErrorf(exitFailure, "Not inside a Laravel application: no artisan file found in the current directory or its parents")
+	}
+	return filepath.Dir(artisan), nil
+}
+
+// envTargetPath returns the environment file selected by --file or --env,
+// defaulting to the .env of the current project. --env=testing selects
+// .env.testing and --env=example selects .env.example.
+func envTargetPath() (string, error) {
+	if envFile != "" && envName != "" {
+		return "", exitErrorf(exitUsage, "--file and --env cannot be used together")
+	}
+	if envFile != "" {
+		return filepath.Abs(envFile)
+	}
+	if strings.ContainsAny(envName, `/\`) || strings.HasPrefix(envName, ".") {
+		return "", exitErrorf(exitUsage, "Invalid environment name %q", envName)
+	}
+
+	root, err := findProjectRoot()
+	if err != nil {
+		return "", err
+	}
+	name := ".env"
+	if envName != "" {
+		name += "." + envName
+	}
+	return filepath.Join(root, name), nil
+}
+
+// loadEnvTarget parses the selected environment file.
+func loadEnvTarget() (string, *dotenv.File, error) {
+	path, err := envTargetPath()
+	if err != nil {
+		return "", nil, err
+	}
+	env, err := dotenv.Load(path)
+	if os.IsNotExist(err) {
+		return path, nil, exitErrorf(exitFailure, "%s does not exist", path)
+	}
+	if err != nil {
+		return path, nil, exitErrorf(exitFailure, "Failed to read %s: %v", path, err)
+	}
+	return path, env, nil
+}
+
+// isSecretKey reports whether a variable name looks like it holds a secret.
+func isSecretKey(key string) bool {
+	for _, word := range strings.FieldsFunc(strings.ToUpper(key), func(r rune) bool { return r == '_' || r == '.' }) {
+		if contains(secretKeyParts, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// maskEnvValue hides secret values. Empty values are shown, and URLs only
+// have their password hidden so the host stays readable.
+func maskEnvValue(key, value string) string {
+	if value == "" {
+		return value
+	}
+	if u, err := url.Parse(value); err == nil && u.Host != "" && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			return strings.Replace(u.String(), "xxxxx", maskedValue, 1)
+		}
+	}
+	if isSecretKey(key) {
+		return maskedValue
+	}
+	return value
+}
+
+var envCmd = &cobra.Command{
+	Use:   "env",
+	Short: "Read and edit the environment files of a Laravel application",
+	Long: "Read and edit the .env file of the Laravel application containing the current directory.\n\n" +
+		"Use --env to select another environment file, such as --env=example for .env.example or\n" +
+		"--env=testing for .env.testing, or --file for any other path. Comments, quoting and the\n" +
+		"order of the file are kept.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var envGetCmd = &cobra.Command{
+	Use:          "get <key>",
+	Short:        "Print the value of a variable",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, env, err := loadEnvTarget()
+		if err != nil {
+			return err
+		}
+		value, ok := env.Get(args[0])
+		if !ok {
+			return exitErrorf(exitFailure, "%s is not set in %s", args[0], path)
+		}
+		fmt.Fprintln(stdout, value)
+		return nil
+	},
+}
+
+var envSetCmd = &cobra.Command{
+	Use:          "set <key> <value>",
+	Short:        "Set a variable, adding it when it is missing",
+	Args:         usageArgs(cobra.ExactArgs(2)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, value := args[0], args[1]
+		if !dotenv.ValidKey(key) {
+			return exitErrorf(exitUsage, "Invalid variable name %q", key)
+		}
+		path, err := envTargetPath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if err := dotenv.WriteFile(path, nil); err != nil {
+				return err
+			}
+		}
+
+		// Reuse a commented out line such as `# DB_HOST=127.0.0.1` for the key
+		changes := []envChange{{Action: "uncomment", Key: key, Value: value}, {Action: "set", Key: key, Value: value}}
+		if err := applyEnvChanges(path, changes...); err != nil {
+			return exitErrorf(exitFailure, "Failed to update %s: %v", path, err)
+		}
+		fmt.Fprintf(stdout, "Set %s in %s\n", key, path)
+		return nil
+	},
+}
+
+var envUnsetCmd = &cobra.Command{
+	Use:          "unset <key>",
+	Short:        "Remove a variable",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, env, err := loadEnvTarget()
+		if err != nil {
+			return err
+		}
+		if !env.Unset(args[0]) {
+			fmt.Fprintf(stdout, "%s is not set in %s\n", args[0], path)
+			return nil
+		}
+		if err := env.Save(path); err != nil {
+			return exitErrorf(exitFailure, "Failed to update %s: %v", path, err)
+		}
+		fmt.Fprintf(stdout, "Removed %s from %s\n", args[0], path)
+		return nil
+	},
+}
+
+var envListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List every variable, masking secrets unless --reveal is given",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, env, err := loadEnvTarget()
+		if err != nil {
+			return err
+		}
+		for _, key := range env.Keys() {
+			value, _ := env.Get(key)
+			if !envReveal {
+				value = maskEnvValue(key, value)
+			}
+			fmt.Fprintln(stdout, dotenv.Format(key, value))
+		}
+		return nil
+	},
+}
+
+func init() {
+	envCmd.PersistentFlags().StringVar(&envName, "env", "", "Use the .env.<name> file, such as example or testing")
+	envCmd.PersistentFlags().StringVar(&envFile, "file", "", "Use the environment file at this path")
+	envListCmd.Flags().BoolVar(&envReveal, "reveal", false, "Show secret values instead of masking them")
+	envCmd.AddCommand(envGetCmd, envSetCmd, envUnsetCmd, envListCmd)
+	rootCmd.AddCommand(envCmd)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"laravel-cli/dotenv"
+	"laravel-cli/encryption"
+
+	"github.com/spf13/cobra"
+)
+
+// envEncryptionKeyVariable holds the key when --key is not given, as it does
+// for `php artisan env:decrypt`.
+const envEncryptionKeyVariable = "LARAVEL_ENV_ENCRYPTION_KEY"
+
+var (
+	envKey      string
+	envCipher   string
+	envForce    bool
+	envPrune    bool
+	envFilename string
+)
+
+// envEncryptionKey returns the key from --key or LARAVEL_ENV_ENCRYPTION_KEY.
+func envEncryptionKey() string {
+	if envKey != "" {
+		return envKey
+	}
+	return os.Getenv(envEncryptionKeyVariable)
+}
+
+func newEnvEncrypter(key []byte) (*encryption.Encrypter, error) {
+	e, err := encryption.New(key, envCipher)
+	if err != nil {
+		return nil, exitErrorf(exitUsage, "Invalid encryption key or cipher: %v", err)
+	}
+	return e, nil
+}
+
+var envEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt an environment file into <file>.encrypted",
+	Long: "Encrypt .env, or the file selected with --env or --file, into a .encrypted file next to it.\n\n" +
+		"The result is compatible with `php artisan env:decrypt`. The key is read from --key, then from\n" +
+		"LARAVEL_ENV_ENCRYPTION_KEY; without either a new key is generated and printed.",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := envTargetPath()
+		if err != nil {
+			return err
+		}
+		encryptedPath := path + ".encrypted"
+
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return exitErrorf(exitFailure, "Failed to read %s: %v", path, err)
+		}
+		if _, err := os.Stat(encryptedPath); err == nil && !envForce {
+			return exitErrorf(exitFailure, "%s already exists. Use --force to overwrite it", encryptedPath)
+		}
+
+		keyString, generated := envEncryptionKey(), false
+		var key []byte
+		if keyString == "" {
+			if key, err = encryption.GenerateKey(envCipher); err != nil {
+				return exitErrorf(exitUsage, "Failed to generate a key: %v", err)
+			}
+			keyString, generated = encryption.FormatKey(key), true
+		} else if key, err = encryption.ParseKey(keyString); err != nil {
+			return exitErrorf(exitUsage, "Invalid encryption key: %v", err)
+		}
+
+		e, err := newEnvEncrypter(key)
+		if err != nil {
+			return err
+		}
+		encrypted, err := e.EncryptString(string(contents))
+		if err != nil {
+			return exitErrorf(exitFailure, "Failed to encrypt %s: %v", path, err)
+		}
+		if err := dotenv.WriteFile(encryptedPath, []byte(encrypted)); err != nil {
+			return exitErrorf(exitFailure, "Failed to write %s: %v", encryptedPath, err)
+		}
+		if envPrune {
+			if err := os.Remove(path); err != nil {
+				return exitErrorf(exitFailure, "Failed to remove %s: %v", path, err)
+			}
+		}
+
+		fmt.Fprintf(stdout, "Encrypted %s into %s\n", filepath.Base(path), encryptedPath)
+		if generated {
+			fmt.Fprintf(stdout, "Key: %s\n", keyString)
+			fmt.Fprintln(stdout, "Store this key safely; it is needed to decrypt the file and is not saved anywhere.")
+		}
+		return nil
+	},
+}
+
+var envDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt <file>.encrypted back into an environment file",
+	Long: "Decrypt .env.encrypted, or the file selected with --env or --file followed by .encrypted.\n\n" +
+		"Files encrypted with `php artisan env:encrypt` are supported. The key is read from --key, then\n" +
+		"from LARAVEL_ENV_ENCRYPTION_KEY.",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := envTargetPath()
+		if err != nil {
+			return err
+		}
+		encryptedPath := path + ".encrypted"
+		if envFilename != "" && filepath.IsAbs(envFilename) {
+			path = envFilename
+		} else if envFilename != "" {
+			path = filepath.Join(filepath.Dir(path), envFilename)
+		}
+
+		keyString := envEncryptionKey()
+		if keyString == "" {
+			return exitErrorf(exitUsage, "A decryption key is required. Pass --key or set %s", envEncryptionKeyVariable)
+		}
+		key, err := encryption.ParseKey(keyString)
+		if err != nil {
+			return exitErrorf(exitUsage, "Invalid encryption key: %v", err)
+		}
+		e, err := newEnvEncrypter(key)
+		if err != nil {
+			return err
+		}
+
+		encrypted, err := os.ReadFile(encryptedPath)
+		if err != nil {
+			return exitErrorf(exitFailure, "Failed to read %s: %v", encryptedPath, err)
+		}
+		if _, err := os.Stat(path); err == nil && !envForce {
+			return exitErrorf(exitFailure, "%s already exists. Use --force to overwrite it", path)
+		}
+
+		contents, err := e.DecryptString(string(encrypted))
+		if errors.Is(err, encryption.ErrInvalidPayload) {
+			return exitErrorf(exitFailure, "%s is not a valid encrypted environment file", encryptedPath)
+		} else if err != nil {
+			return exitErrorf(exitFailure, "Failed to decrypt %s: %v. Check the key and the cipher", encryptedPath, err)
+		}
+		if err := dotenv.WriteFile(path, []byte(contents)); err != nil {
+			return exitErrorf(exitFailure, "Failed to write %s: %v", path, err)
+		}
+
+		fmt.Fprintf(stdout, "Decrypted %s into %s\n", filepath.Base(encryptedPath), path)
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{envEncryptCmd, envDecryptCmd} {
+		cmd.Flags().StringVar(&envKey, "key", "", "The encryption key, such as base64:... (default $"+envEncryptionKeyVariable+")")
+		cmd.Flags().StringVar(&envCipher, "cipher", encryption.DefaultCipher, "The cipher: AES-128-CBC, AES-256-CBC, AES-128-GCM or AES-256-GCM")
+		cmd.Flags().BoolVar(&envForce, "force", false, "Overwrite the existing output file")
+	}
+	envEncryptCmd.Flags().BoolVar(&envPrune, "prune", false, "Delete the plain environment file after encrypting it")
+	envDecryptCmd.Flags().StringVar(&envFilename, "filename", "", "Write the decrypted file under this name instead")
+	envCmd.AddCommand(envEncryptCmd, envDecryptCmd)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvEncryptDecryptRoundTrip(t *testing.T) {
+	const contents = "APP_NAME=\"My Shop\"\n# Secrets\nSTRIPE_SECRET=sk_live_123\n"
+	root := useEnvProject(t, "")
+	writeTestFile(filepath.Join(root, ".env.production"), contents)
+	t.Setenv(envEncryptionKeyVariable, "")
+	envName, envPrune = "production", true
+
+	if err := envEncryptCmd.RunE(envEncryptCmd, nil); err != nil {
+		t.Fatalf("env encrypt returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".env.production")); !os.IsNotExist(err) {
+		t.Error("Expected --prune to delete the plain file")
+	}
+
+	// The generated key is printed
+	output := stdout.(*bytes.Buffer).String()
+	start := strings.Index(output, "Key: ")
+	if start < 0 {
+		t.Fatalf("Expected the generated key to be printed, got:\n%s", output)
+	}
+	key := strings.Fields(output[start+len("Key: "):])[0]
+
+	t.Setenv(envEncryptionKeyVariable, key)
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); err != nil {
+		t.Fatalf("env decrypt returned error: %v", err)
+	}
+	if got, _ := readTestFile(filepath.Join(root, ".env.production")); got != contents {
+		t.Errorf("Decrypted file = %q; want %q", got, contents)
+	}
+
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected an existing file not to be overwritten, got %v", err)
+	}
+	envFilename = ".env"
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); err == nil {
+		t.Error("Expected the existing .env not to be overwritten")
+	}
+	envForce = true
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); err != nil {
+		t.Fatalf("env decrypt --force returned error: %v", err)
+	}
+	if got, _ := readTestFile(filepath.Join(root, ".env")); got != contents {
+		t.Errorf("Expected --filename to write .env, got %q", got)
+	}
+}
+
+func TestEnvDecryptErrors(t *testing.T) {
+	root := useEnvProject(t, "APP_NAME=Shop\n")
+	t.Setenv(envEncryptionKeyVariable, "")
+	envCipher, envKey = "aes-128-gcm", "base64:AAECAwQFBgcICQoLDA0ODw=="
+
+	if err := envEncryptCmd.RunE(envEncryptCmd, nil); err != nil {
+		t.Fatalf("env encrypt returned error: %v", err)
+	}
+	if strings.Contains(stdout.(*bytes.Buffer).String(), "Key:") {
+		t.Error("Expected a given key not to be printed")
+	}
+	os.Remove(filepath.Join(root, ".env"))
+
+	envKey = ""
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), envEncryptionKeyVariable) {
+		t.Errorf("Expected a missing key to be a usage error, got %v", err)
+	}
+
+	envKey = "base64://///////////////////w=="
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); exitCodeFor(err) != exitFailure || !strings.Contains(err.Error(), "Check the key") {
+		t.Errorf("Expected a wrong key to fail, got %v", err)
+	}
+
+	envKey = "base64:AAECAwQFBgcICQoLDA0ODw=="
+	envCipher = "AES-256-CBC"
+	if err := envDecryptCmd.RunE(envDecryptCmd, nil); exitCodeFor(err) != exitUsage {
+		t.Errorf("Expected a key of the wrong size to be a usage error, got %v", err)
+	}
+}
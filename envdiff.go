@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"laravel-cli/dotenv"
+
+	"github.com/spf13/cobra"
+)
+
+var envAgainst string
+
+// placeholderPattern matches values that are meant to be replaced, such as
+// "changeme", "your-api-key" or "<token>".
+var placeholderPattern = regexp.MustCompile(`(?i)^(change[-_ ]?me|todo|tbd|x{3,}|\*{3,}|\.\.\.|<[^>]*>|your[-_ ].*)$`)
+
+// envDiff lists the differences between an environment file and the
+// reference it was created from, usually .env and .env.example.
+type envDiff struct {
+	Target, Reference string // File names used in the report
+
+	MissingFromTarget    []string // Set in the reference only
+	MissingFromReference []string // Set in the target only
+	CommentedInTarget    []string // Active in the reference, commented out in the target
+	CommentedInReference []string // Active in the target, commented out in the reference
+	Placeholders         []string // Still at a placeholder value in the target
+}
+
+func (d *envDiff) empty() bool {
+	return len(d.MissingFromTarget)+len(d.MissingFromReference)+len(d.CommentedInTarget)+len(d.CommentedInReference)+len(d.Placeholders) == 0
+}
+
+// commentedKeys returns the keys that only appear commented out in env.
+func commentedKeys(env *dotenv.File) []string {
+	var keys []string
+	for _, line := range env.Lines {
+		if line.Kind == dotenv.Assignment && line.Commented && !env.Has(line.Key) && !contains(keys, line.Key) {
+			keys = append(keys, line.Key)
+		}
+	}
+	return keys
+}
+
+// isPlaceholder reports whether a value still has to be filled in. An empty
+// APP_KEY counts as one because Laravel refuses to run without it.
+func isPlaceholder(key, value string) bool {
+	return placeholderPattern.MatchString(value) || (key == "APP_KEY" && value == "")
+}
+
+func diffEnvFiles(target, reference *dotenv.File) *envDiff {
+	d := &envDiff{}
+	targetCommented, referenceCommented := commentedKeys(target), commentedKeys(reference)
+
+	for _, key := range reference.Keys() {
+		if contains(targetCommented, key) {
+			d.CommentedInTarget = append(d.CommentedInTarget, key)
+		} else if !target.Has(key) {
+			d.MissingFromTarget = append(d.MissingFromTarget, key)
+		}
+	}
+	for _, key := range target.Keys() {
+		if contains(referenceCommented, key) {
+			d.CommentedInReference = append(d.CommentedInReference, key)
+		} else if !reference.Has(key) {
+			d.MissingFromReference = append(d.MissingFromReference, key)
+		}
+		if value, _ := target.Get(key); isPlaceholder(key, value) {
+			d.Placeholders = append(d.Placeholders, key)
+		}
+	}
+	return d
+}
+
+func printEnvDiff(d *envDiff) {
+	if d.empty() {
+		fmt.Fprintf(stdout, "%s is in sync with %s\n", d.Target, d.Reference)
+		return
+	}
+
+	sections := []struct {
+		title string
+		keys  []string
+	}{
+		{fmt.Sprintf("Missing from %s", d.Target), d.MissingFromTarget},
+		{fmt.Sprintf("Missing from %s", d.Reference), d.MissingFromReference},
+		{fmt.Sprintf("Commented out in %s but set in %s", d.Target, d.Reference), d.CommentedInTarget},
+		{fmt.Sprintf("Commented out in %s but set in %s", d.Reference, d.Target), d.CommentedInReference},
+		{fmt.Sprintf("Still at a placeholder value in %s", d.Target), d.Placeholders},
+	}
+	for _, section := range sections {
+		if len(section.keys) == 0 {
+			continue
+		}
+		fmt.Fprintf(stdout, "%s:\n", section.title)
+		for _, key := range section.keys {
+			fmt.Fprintf(stdout, "  %s\n", key)
+		}
+	}
+}
+
+// loadEnvPair loads the selected environment file and the reference it is
+// compared with: --against, or the .env.example next to it.
+func loadEnvPair() (targetPath string, target *dotenv.File, referencePath string, reference *dotenv.File, err error) {
+	targetPath, target, err = loadEnvTarget()
+	if err != nil {
+		return
+	}
+
+	referencePath = filepath.Join(filepath.Dir(targetPath), ".env.example")
+	if envAgainst != "" {
+		if referencePath, err = filepath.Abs(envAgainst); err != nil {
+			return
+		}
+	}
+	if referencePath == targetPath {
+		err = exitErrorf(exitUsage, "Cannot compare %s with itself; select another file with --against", filepath.Base(targetPath))
+		return
+	}
+
+	reference, err = dotenv.Load(referencePath)
+	if err != nil {
+		err = exitErrorf(exitFailure, "Failed to read %s: %v", referencePath, err)
+	}
+	return
+}
+
+var envDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Report the differences between .env and .env.example",
+	Long: "Report keys missing from .env or .env.example, keys that are commented out in one file but\n" +
+		"set in the other, and values in .env that are still placeholders.\n\n" +
+		"Exits with status 13 when the files differ, so it can be used as a pre-commit or CI check.",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetPath, target, referencePath, reference, err := loadEnvPair()
+		if err != nil {
+			return err
+		}
+
+		d := diffEnvFiles(target, reference)
+		d.Target, d.Reference = filepath.Base(targetPath), filepath.Base(referencePath)
+		printEnvDiff(d)
+		if !d.empty() {
+			return exitErrorf(exitEnvDrift, "%s and %s differ", d.Target, d.Reference)
+		}
+		return nil
+	},
+}
+
+var envSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Add the keys missing from .env using .env.example",
+	Long: "Add every key of .env.example that is missing from .env, asking for each value with the\n" +
+		"example value as the default. Without interaction the example values are used as they are.\n" +
+		"Keys that are commented out in .env are left alone.",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetPath, target, _, reference, err := loadEnvPair()
+		if err != nil {
+			return err
+		}
+
+		d := diffEnvFiles(target, reference)
+		if len(d.MissingFromTarget) == 0 {
+			fmt.Fprintf(stdout, "No keys are missing from %s\n", filepath.Base(targetPath))
+			return nil
+		}
+
+		prompt := newPrompter(nil)
+		for _, key := range d.MissingFromTarget {
+			value, _ := reference.Get(key)
+			target.Set(key, askForString(prompt, key, key, value))
+		}
+		if err := target.Save(targetPath); err != nil {
+			return exitErrorf(exitFailure, "Failed to update %s: %v", targetPath, err)
+		}
+		fmt.Fprintf(stdout, "Added %s to %s\n", strings.Join(d.MissingFromTarget, ", "), filepath.Base(targetPath))
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{envDiffCmd, envSyncCmd} {
+		cmd.Flags().StringVar(&envAgainst, "against", "", "Compare with this file instead of .env.example")
+	}
+	envSyncCmd.Flags().BoolVarP(&noInteraction, "no-interaction", "n", false, "Use the example values without asking")
+	envCmd.AddCommand(envDiffCmd, envSyncCmd)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testEnvReference = `APP_NAME=Laravel
+APP_KEY=
+STRIPE_SECRET=your-stripe-secret
+
+DB_CONNECTION=mysql
+DB_HOST=127.0.0.1
+# REDIS_PASSWORD=null
+MAIL_MAILER=log
+`
+
+func TestEnvDiff(t *testing.T) {
+	root := useEnvProject(t, "APP_NAME=Shop\nAPP_KEY=base64:abc=\nSTRIPE_SECRET=your-stripe-secret\nDB_CONNECTION=sqlite\n# DB_HOST=127.0.0.1\nREDIS_PASSWORD=secret\nSENTRY_DSN=https://sentry.test\n")
+	writeTestFile(filepath.Join(root, ".env.example"), testEnvReference)
+
+	err := envDiffCmd.RunE(envDiffCmd, nil)
+	if exitCodeFor(err) != exitEnvDrift {
+		t.Fatalf("Expected exit code %d for drift, got %v", exitEnvDrift, err)
+	}
+
+	want := `Missing from .env:
+  MAIL_MAILER
+Missing from .env.example:
+  SENTRY_DSN
+Commented out in .env but set in .env.example:
+  DB_HOST
+Commented out in .env.example but set in .env:
+  REDIS_PASSWORD
+Still at a placeholder value in .env:
+  STRIPE_SECRET
+`
+	if got := stdout.(*bytes.Buffer).String(); got != want {
+		t.Errorf("Unexpected report:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEnvDiffInSync(t *testing.T) {
+	root := useEnvProject(t, "APP_NAME=Shop\nAPP_KEY=base64:abc=\n")
+	writeTestFile(filepath.Join(root, ".env.example"), "APP_NAME=Laravel\nAPP_KEY=\n")
+
+	if err := envDiffCmd.RunE(envDiffCmd, nil); err != nil {
+		t.Fatalf("Expected no drift, got %v", err)
+	}
+	if got := stdout.(*bytes.Buffer).String(); got != ".env is in sync with .env.example\n" {
+		t.Errorf("Unexpected report: %q", got)
+	}
+
+	// An empty APP_KEY has to be generated
+	writeTestFile(filepath.Join(root, ".env"), "APP_NAME=Shop\nAPP_KEY=\n")
+	if err := envDiffCmd.RunE(envDiffCmd, nil); exitCodeFor(err) != exitEnvDrift {
+		t.Errorf("Expected an empty APP_KEY to be reported, got %v", err)
+	}
+}
+
+func TestEnvSync(t *testing.T) {
+	root := useEnvProject(t, "APP_NAME=Shop\n# DB_HOST=127.0.0.1\n")
+	writeTestFile(filepath.Join(root, ".env.example"), testEnvReference)
+	// Keep APP_KEY empty, choose Stripe's secret and accept the other defaults
+	stdin = strings.NewReader("\nsk_test_123\n\n\n")
+
+	if err := envSyncCmd.RunE(envSyncCmd, nil); err != nil {
+		t.Fatalf("env sync returned error: %v", err)
+	}
+
+	env, _ := readTestFile(filepath.Join(root, ".env"))
+	want := "APP_NAME=Shop\n# DB_HOST=127.0.0.1\nAPP_KEY=\nSTRIPE_SECRET=sk_test_123\nDB_CONNECTION=mysql\nMAIL_MAILER=log\n"
+	if env != want {
+		t.Errorf("Unexpected .env after sync:\n%s\nwant:\n%s", env, want)
+	}
+}
+
+func TestEnvSyncWithoutInteraction(t *testing.T) {
+	root := useEnvProject(t, "APP_NAME=Shop\n")
+	writeTestFile(filepath.Join(root, ".env.testing"), "APP_ENV=testing\nDB_DATABASE=:memory:\n")
+	noInteraction, envAgainst = true, filepath.Join(root, ".env.testing")
+
+	if err := envSyncCmd.RunE(envSyncCmd, nil); err != nil {
+		t.Fatalf("env sync returned error: %v", err)
+	}
+	if env, _ := readTestFile(filepath.Join(root, ".env")); env != "APP_NAME=Shop\nAPP_ENV=testing\nDB_DATABASE=:memory:\n" {
+		t.Errorf("Unexpected .env after sync: %q", env)
+	}
+
+	envName = "testing"
+	if err := envSyncCmd.RunE(envSyncCmd, nil); exitCodeFor(err) != exitUsage {
+		t.Errorf("Expected comparing a file with itself to be a usage error, got %v", err)
+	}
+}
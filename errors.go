@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Exit codes returned by the CLI. Wrapper scripts can rely on these values;
+// they are also listed in the README and in `laravel new --help`.
+const (
+	exitOK              = 0   // Success
+	exitFailure         = 1   // Unexpected error
+	exitUsage           = 2   // Invalid arguments, flags or project name
+	exitMissingTool     = 3   // Composer or PHP is missing or too old
+	exitDirectoryExists = 4   // The target directory exists and --force was not given
+	exitProjectCreation = 5   // composer create-project, the offline cache or the .env setup (including the app key) failed
+	exitPostInstall     = 6   // Post-install scripts failed (--strict)
+	exitMigrationFailed = 7   // Database migrations failed (--strict)
+	exitGitFailed       = 8   // Git repository setup failed (--strict)
+	exitGitHubFailed    = 9   // GitHub repository creation failed (--strict)
+	exitPestFailed      = 10  // Pest installation failed (--strict)
+	exitNpmFailed       = 11  // The frontend install or build failed (--strict)
+	exitPresetFailed    = 12  // Preset packages or artisan commands failed (--strict)
+	exitEnvDrift        = 13  // laravel env diff found differences
+	exitDatabaseFailed  = 14  // The database could not be created (--strict)
+	exitDoctorFailed    = 15  // laravel doctor found a failing check
+	exitInterrupted     = 130 // Interrupted by Ctrl+C or SIGTERM
+)
+
+// exitCodeDescriptions documents every exit code in --help output.
+var exitCodeDescriptions = []struct {
+	code        int
+	description string
+}{
+	{exitOK, "success"},
+	{exitFailure, "unexpected error"},
+	{exitUsage, "invalid arguments, flags or project name"},
+	{exitMissingTool, "Composer or PHP is missing or too old"},
+	{exitDirectoryExists, "the target directory already exists"},
+	{exitProjectCreation, "composer create-project, the offline cache or the .env setup (including the app key) failed"},
+	{exitPostInstall, "post-install scripts failed (--strict)"},
+	{exitMigrationFailed, "database migrations failed (--strict)"},
+	{exitGitFailed, "Git repository setup failed (--strict)"},
+	{exitGitHubFailed, "GitHub repository creation failed (--strict)"},
+	{exitPestFailed, "Pest installation failed (--strict)"},
+	{exitNpmFailed, "the frontend install or build failed (--strict)"},
+	{exitPresetFailed, "preset packages or artisan commands failed (--strict)"},
+	{exitEnvDrift, "the environment files differ (laravel env diff)"},
+	{exitDatabaseFailed, "the database could not be created (--strict)"},
+	{exitDoctorFailed, "a check failed (laravel doctor)"},
+	{exitInterrupted, "interrupted by Ctrl+C or SIGTERM"},
+}
+
+// exitError pairs an error with the exit code the process should end with.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// newExitError wraps err with an exit code.
+func newExitError(code int, err error) error {
+	return &exitError{code: code, err: err}
+}
+
+// exitErrorf formats an error with an exit code.
+func exitErrorf(code int, format string, args ...interface{}) error {
+	return &exitError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// exitCodeFor maps an error returned by a command to the process exit code.
+func exitCodeFor(err error) int {
+	if err == nil {
+		return exitOK
+	}
+
+	var interrupt *interruptError
+	if errors.As(err, &interrupt) {
+		return exitInterrupted
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return exitFailure
+}
+
+// warn reports a failure that does not stop the installation. In --strict
+// mode, or once the installer has been interrupted, the failure is returned
+// instead so the caller stops and the project is rolled back.
+func warn(ctx context.Context, code int, err error, message string) error {
+	if isInterrupted(ctx) {
+		return context.Cause(ctx)
+	}
+	if strict {
+		return newExitError(code, fmt.Errorf("%s: %w", message, err))
+	}
+	printWarning(ctx, fmt.Sprintf("%s: %v", message, err))
+	return nil
+}
+
+// printWarning shows a warning, or emits it as an event in --format=json mode.
+func printWarning(ctx context.Context, message string) {
+	if events.enabled() {
+		emitWarning(ctx, message)
+		return
+	}
+	fmt.Fprintf(stepStdout(ctx), "Warning: %s\n", message)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"plain", errors.New("boom"), exitFailure},
+		{"typed", exitErrorf(exitDirectoryExists, "exists"), exitDirectoryExists},
+		{"wrapped", fmt.Errorf("outer: %w", newExitError(exitMissingTool, errors.New("composer"))), exitMissingTool},
+		{"interrupt", &interruptError{signal: syscall.SIGINT}, exitInterrupted},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitCodeFor(tc.err); got != tc.want {
+				t.Errorf("exitCodeFor(%v) = %d; want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCreateNewProjectErrorCodes(t *testing.T) {
+	t.Run("InvalidName", func(t *testing.T) {
+		useFakeRunner(t, "")
+		if code := exitCodeFor(createNewProject("my project")); code != exitUsage {
+			t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+		}
+	})
+
+	t.Run("InvalidDatabase", func(t *testing.T) {
+		useFakeRunner(t, "")
+		database = "oracle"
+		if code := exitCodeFor(createNewProject("demo")); code != exitUsage {
+			t.Errorf("Expected exit code %d, got %d", exitUsage, code)
+		}
+	})
+
+	t.Run("DirectoryExists", func(t *testing.T) {
+		useFakeRunner(t, "")
+		os.Mkdir("demo", 0755)
+		if code := exitCodeFor(createNewProject("demo")); code != exitDirectoryExists {
+			t.Errorf("Expected exit code %d, got %d", exitDirectoryExists, code)
+		}
+	})
+
+	t.Run("ComposerFailureRollsBack", func(t *testing.T) {
+		fake := useFakeRunner(t, "")
+		quiet = true
+		fake.handler = func(cmd Command) (*Result, error) {
+			fakeSkeletonHandler(cmd)
+			return &Result{ExitCode: 1}, &CommandError{Command: cmd, ExitCode: 1}
+		}
+
+		if code := exitCodeFor(createNewProject("demo")); code != exitProjectCreation {
+			t.Errorf("Expected exit code %d, got %d", exitProjectCreation, code)
+		}
+		if _, err := os.Stat("demo"); !os.IsNotExist(err) {
+			t.Error("Expected the partial project to be rolled back")
+		}
+	})
+}
+
+func TestStrictMode(t *testing.T) {
+	failGit := func(cmd Command) (*Result, error) {
+		if cmd.Name == "git" && len(cmd.Args) > 0 && cmd.Args[0] == "commit" {
+			return &Result{ExitCode: 1}, &CommandError{Command: cmd, ExitCode: 1}
+		}
+		return fakeSkeletonHandler(cmd)
+	}
+
+	t.Run("WarningsByDefault", func(t *testing.T) {
+		fake := useFakeRunner(t, "\n\n")
+		fake.handler = failGit
+		quiet, git, branch, database = true, true, "main", "mysql"
+
+		if err := createNewProject("demo"); err != nil {
+			t.Fatalf("Expected git failures to be warnings, got %v", err)
+		}
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		fake := useFakeRunner(t, "\n\n")
+		fake.handler = failGit
+		quiet, git, branch, database, strict = true, true, "main", "mysql", true
+
+		if code := exitCodeFor(createNewProject("demo")); code != exitGitFailed {
+			t.Errorf("Expected exit code %d, got %d", exitGitFailed, code)
+		}
+		if _, err := os.Stat("demo"); !os.IsNotExist(err) {
+			t.Error("Expected the failed project to be rolled back")
+		}
+	})
+}
+
+func TestWarnReturnsInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(&interruptError{signal: syscall.SIGINT})
+
+	err := warn(ctx, exitNpmFailed, errors.New("npm failed"), "NPM command failed")
+	if exitCodeFor(err) != exitInterrupted {
+		t.Errorf("Expected an interrupt error, got %v", err)
+	}
+}
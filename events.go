@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// event is a single line of the `laravel new --format=json` NDJSON stream.
+type event struct {
+	Event      string    `json:"event"`
+	Time       time.Time `json:"time"`
+	Step       string    `json:"step,omitempty"`
+	DurationMs int64     `json:"duration_ms,omitempty"`
+	Commands   []string  `json:"commands,omitempty"`
+	Message    string    `json:"message,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	ExitCode   int       `json:"exit_code,omitempty"`
+	Summary    *summary  `json:"summary,omitempty"`
+}
+
+// summary is carried by the final event of a run.
+type summary struct {
+	Status     string `json:"status"`
+	Project    string `json:"project"`
+	Path       string `json:"path"`
+	Database   string `json:"database,omitempty"`
+	StarterKit string `json:"starter_kit,omitempty"`
+	Preset     string `json:"preset,omitempty"`
+	Branch     string `json:"branch,omitempty"`
+	GitHubURL  string `json:"github_url,omitempty"`
+}
+
+// eventStream writes events as NDJSON. It is a no-op until started, which
+// only happens in --format=json mode.
+type eventStream struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+}
+
+var events = &eventStream{}
+
+func (s *eventStream) start(w io.Writer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoder = json.NewEncoder(w)
+}
+
+func (s *eventStream) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.encoder = nil
+}
+
+func (s *eventStream) enabled() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.encoder != nil
+}
+
+func (s *eventStream) emit(e event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.encoder == nil {
+		return
+	}
+	e.Time = time.Now().UTC()
+	s.encoder.Encode(e)
+}
+
+// stepRecorder collects the commands a step runs.
+type stepRecorder struct {
+	mu       sync.Mutex
+	name     string
+	commands []string
+}
+
+type stepRecorderKey struct{}
+
+func (r *stepRecorder) record(cmd Command) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, cmd.String())
+}
+
+func (r *stepRecorder) list() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.commands...)
+}
+
+func currentStep(ctx context.Context) *stepRecorder {
+	rec, _ := ctx.Value(stepRecorderKey{}).(*stepRecorder)
+	return rec
+}
+
+// runStep runs fn as a named installation step and emits started and
+// finished or failed events around it.
+func runStep(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	rec := &stepRecorder{name: name}
+	ctx = context.WithValue(ctx, stepRecorderKey{}, rec)
+
+	events.emit(event{Event: "started", Step: name})
+	start := time.Now()
+	err := fn(ctx)
+
+	e := event{Event: "finished", Step: name, DurationMs: time.Since(start).Milliseconds(), Commands: rec.list()}
+	if err != nil {
+		e.Event = "failed"
+		e.Error = err.Error()
+		e.ExitCode = exitCodeFor(err)
+	}
+	events.emit(e)
+	return err
+}
+
+// skipStep emits a skipped event for a step that was not requested.
+func skipStep(name, reason string) {
+	events.emit(event{Event: "skipped", Step: name, Message: reason})
+}
+
+// emitWarning reports a non-fatal problem as an event.
+func emitWarning(ctx context.Context, message string) {
+	e := event{Event: "warning", Message: message}
+	if rec := currentStep(ctx); rec != nil {
+		e.Step = rec.name
+	}
+	events.emit(e)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func decodeEvents(t *testing.T, data []byte) []event {
+	t.Helper()
+
+	var decoded []event
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("Expected NDJSON, got %q: %v", line, err)
+		}
+		decoded = append(decoded, e)
+	}
+	return decoded
+}
+
+func TestJSONEventStream(t *testing.T) {
+	fake := useFakeRunner(t, "\n\n")
+	var out bytes.Buffer
+	stdout = &out
+	promptOut = &bytes.Buffer{}
+	outputFormat = "json"
+	git, branch, database = true, "trunk", "pgsql"
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	if len(fake.commands) == 0 {
+		t.Fatal("Expected commands to run")
+	}
+
+	decoded := decodeEvents(t, out.Bytes())
+	var sequence []string
+	for _, e := range decoded {
+		sequence = append(sequence, e.Event+":"+e.Step)
+	}
+	want := []string{
+		"started:tools", "finished:tools",
+		"started:create_project", "finished:create_project",
+		"started:post_install", "finished:post_install",
+		"started:setup", "finished:setup",
+		"skipped:pest", "skipped:npm_install", "skipped:npm_build",
+		"started:git", "finished:git",
+		"skipped:github",
+		"completed:",
+	}
+	if strings.Join(sequence, ",") != strings.Join(want, ",") {
+		t.Errorf("Unexpected event sequence:\n%v\nwant:\n%v", sequence, want)
+	}
+
+	for _, e := range decoded {
+		if e.Event == "finished" && e.Step == "git" {
+			if len(e.Commands) != 4 || e.Commands[0] != "git init -q" {
+				t.Errorf("Expected git commands on the finished event, got %v", e.Commands)
+			}
+		}
+	}
+
+	final := decoded[len(decoded)-1]
+	if final.Summary == nil || final.Summary.Database != "pgsql" || final.Summary.Branch != "trunk" || final.Summary.Status != "success" {
+		t.Errorf("Unexpected summary: %+v", final.Summary)
+	}
+}
+
+func TestJSONEventStreamFailure(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	var out bytes.Buffer
+	stdout = &out
+	outputFormat = "json"
+	fake.handler = func(cmd Command) (*Result, error) {
+		return &Result{ExitCode: 1}, &CommandError{Command: cmd, ExitCode: 1}
+	}
+
+	if err := createNewProject("demo"); err == nil {
+		t.Fatal("Expected createNewProject to fail")
+	}
+
+	decoded := decodeEvents(t, out.Bytes())
+	final := decoded[len(decoded)-1]
+	if final.Event != "failed" || final.ExitCode != exitProjectCreation || final.Summary.Status != "failed" {
+		t.Errorf("Unexpected final event: %+v", final)
+	}
+
+	failedStep := decoded[len(decoded)-2]
+	if failedStep.Event != "failed" || failedStep.Step != "create_project" || len(failedStep.Commands) != 1 {
+		t.Errorf("Expected create_project to fail with its command, got %+v", failedStep)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"laravel-cli/dotenv"
+	"laravel-cli/encryption"
+
+	"github.com/spf13/cobra"
+)
+
+// cipherPattern finds the cipher in config/app.php, written either as a
+// literal or as the default of an env() call.
+var cipherPattern = regexp.MustCompile(`'cipher'\s*=>\s*(?:env\(\s*'\w+'\s*,\s*)?'([A-Za-z0-9-]+)'`)
+
+var (
+	keyShow  bool
+	keyForce bool
+)
+
+// appCipher returns the cipher the application is configured with. Recent
+// skeletons only ship config/app.php once it is published, so Laravel's
+// default is used when the file or the setting is missing.
+func appCipher(projectDir string) string {
+	data, err := os.ReadFile(filepath.Join(projectDir, "config", "app.php"))
+	if err != nil {
+		return encryption.DefaultCipher
+	}
+	if match := cipherPattern.FindSubmatch(data); match != nil {
+		return strings.ToUpper(string(match[1]))
+	}
+	return encryption.DefaultCipher
+}
+
+// generateAppKey returns a new key for cipher in the format of APP_KEY.
+func generateAppKey(cipher string) (string, error) {
+	key, err := encryption.GenerateKey(cipher)
+	if err != nil {
+		return "", err
+	}
+	return encryption.FormatKey(key), nil
+}
+
+// ensureAppKey sets APP_KEY in the .env file of the project when it is empty
+// or missing, like `php artisan key:generate` does for a fresh application.
+func ensureAppKey(projectDir string) (bool, error) {
+	envPath := filepath.Join(projectDir, ".env")
+	env, err := dotenv.Load(envPath)
+	if err != nil {
+		return false, err
+	}
+	if value, _ := env.Get("APP_KEY"); value != "" {
+		return false, nil
+	}
+
+	key, err := generateAppKey(appCipher(projectDir))
+	if err != nil {
+		return false, err
+	}
+	env.Uncomment("APP_KEY")
+	env.Set("APP_KEY", key)
+	return true, env.Save(envPath)
+}
+
+// rotateAppKey sets a new APP_KEY and prepends the current one to
+// APP_PREVIOUS_KEYS, so data encrypted with it can still be decrypted.
+func rotateAppKey(env *dotenv.File, newKey string) (previous string) {
+	current, _ := env.Get("APP_KEY")
+	if current != "" {
+		keys := []string{current}
+		existing, _ := env.Get("APP_PREVIOUS_KEYS")
+		for _, key := range strings.Split(existing, ",") {
+			if key = strings.TrimSpace(key); key != "" && !contains(keys, key) && key != newKey {
+				keys = append(keys, key)
+			}
+		}
+		env.SetAfter("APP_PREVIOUS_KEYS", strings.Join(keys, ","), "APP_KEY")
+	}
+	env.Set("APP_KEY", newKey)
+	return current
+}
+
+var keyCmd = &cobra.Command{
+	Use:   "key",
+	Short: "Generate and rotate the application key",
+	Long: "Manage APP_KEY in the .env file of the Laravel application containing the current directory.\n\n" +
+		"Keys are generated for the cipher configured in config/app.php, AES-256-CBC by default.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var keyGenerateCmd = &cobra.Command{
+	Use:          "generate",
+	Short:        "Set APP_KEY when it is empty",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := envTargetPath()
+		if err != nil {
+			return err
+		}
+		// The environment file sits next to the config directory
+		key, err := generateAppKey(appCipher(filepath.Dir(path)))
+		if err != nil {
+			return exitErrorf(exitFailure, "Failed to generate a key: %v", err)
+		}
+		if keyShow {
+			fmt.Fprintln(stdout, key)
+			return nil
+		}
+
+		path, env, err := loadEnvTarget()
+		if err != nil {
+			return err
+		}
+		if current, _ := env.Get("APP_KEY"); current != "" && !keyForce {
+			return exitErrorf(exitFailure, "APP_KEY is already set in %s. Use `laravel key rotate` to replace it without losing access to encrypted data, or --force", path)
+		}
+		env.Uncomment("APP_KEY")
+		env.Set("APP_KEY", key)
+		if err := env.Save(path); err != nil {
+			return exitErrorf(exitFailure, "Failed to update %s: %v", path, err)
+		}
+		fmt.Fprintf(stdout, "Set APP_KEY in %s\n", path)
+		return nil
+	},
+}
+
+var keyRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "Replace APP_KEY, keeping the old key in APP_PREVIOUS_KEYS",
+	Long: "Generate a new APP_KEY and move the current key to the front of APP_PREVIOUS_KEYS.\n\n" +
+		"Laravel decrypts data and cookies with the previous keys when the current key fails, so\n" +
+		"users stay logged in while everything is re-encrypted with the new key. Remove old keys\n" +
+		"from APP_PREVIOUS_KEYS once they are no longer needed.",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, env, err := loadEnvTarget()
+		if err != nil {
+			return err
+		}
+
+		key, err := generateAppKey(appCipher(filepath.Dir(path)))
+		if err != nil {
+			return exitErrorf(exitFailure, "Failed to generate a key: %v", err)
+		}
+		previous := rotateAppKey(env, key)
+		if err := env.Save(path); err != nil {
+			return exitErrorf(exitFailure, "Failed to update %s: %v", path, err)
+		}
+
+		if previous == "" {
+			fmt.Fprintf(stdout, "Set APP_KEY in %s; there was no previous key to keep\n", path)
+		} else {
+			fmt.Fprintf(stdout, "Rotated APP_KEY in %s; the previous key was added to APP_PREVIOUS_KEYS\n", path)
+		}
+		return nil
+	},
+}
+
+func init() {
+	keyCmd.PersistentFlags().StringVar(&envName, "env", "", "Use the .env.<name> file, such as testing")
+	keyCmd.PersistentFlags().StringVar(&envFile, "file", "", "Use the environment file at this path")
+	keyGenerateCmd.Flags().BoolVar(&keyShow, "show", false, "Print the key instead of writing it")
+	keyGenerateCmd.Flags().BoolVar(&keyForce, "force", false, "Replace an existing key")
+	keyCmd.AddCommand(keyGenerateCmd, keyRotateCmd)
+	rootCmd.AddCommand(keyCmd)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"laravel-cli/dotenv"
+	"laravel-cli/encryption"
+)
+
+func TestAppCipher(t *testing.T) {
+	testCases := []struct {
+		config string
+		want   string
+	}{
+		{"", "AES-256-CBC"},
+		{"<?php\nreturn [\n    'cipher' => 'AES-128-GCM',\n];\n", "AES-128-GCM"},
+		{"<?php\nreturn [\n    'cipher' => env('APP_CIPHER', 'aes-256-gcm'),\n];\n", "AES-256-GCM"},
+		{"<?php\nreturn ['name' => 'Shop'];\n", "AES-256-CBC"},
+	}
+
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		if tc.config != "" {
+			os.MkdirAll(filepath.Join(dir, "config"), 0755)
+			writeTestFile(filepath.Join(dir, "config", "app.php"), tc.config)
+		}
+		if got := appCipher(dir); got != tc.want {
+			t.Errorf("appCipher() with %q = %s; want %s", tc.config, got, tc.want)
+		}
+	}
+}
+
+func TestEnsureAppKey(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	writeTestFile(envPath, "APP_NAME=Shop\nAPP_KEY=\nAPP_DEBUG=true\n")
+	os.MkdirAll(filepath.Join(dir, "config"), 0755)
+	writeTestFile(filepath.Join(dir, "config", "app.php"), "<?php return ['cipher' => 'AES-128-CBC'];\n")
+
+	generated, err := ensureAppKey(dir)
+	if err != nil || !generated {
+		t.Fatalf("ensureAppKey() = %v, %v; want a generated key", generated, err)
+	}
+	env, _ := dotenv.Load(envPath)
+	value, _ := env.Get("APP_KEY")
+	if key, err := encryption.ParseKey(value); err != nil || len(key) != 16 || !strings.HasPrefix(value, "base64:") {
+		t.Errorf("Expected a 16 byte base64 key for AES-128-CBC, got %q", value)
+	}
+	if content, _ := readTestFile(envPath); !strings.HasPrefix(content, "APP_NAME=Shop\nAPP_KEY=base64:") || !strings.HasSuffix(content, "\nAPP_DEBUG=true\n") {
+		t.Errorf("Expected APP_KEY to be set in place, got:\n%s", content)
+	}
+
+	if generated, _ := ensureAppKey(dir); generated {
+		t.Error("Expected an existing key to be kept")
+	}
+	if again, _ := dotenv.Load(envPath); mustGet(again, "APP_KEY") != value {
+		t.Error("Expected the existing key not to change")
+	}
+}
+
+func TestRotateAppKey(t *testing.T) {
+	env, _ := dotenv.Parse([]byte("APP_KEY=base64:current\nAPP_URL=http://localhost\n"))
+
+	if previous := rotateAppKey(env, "base64:second"); previous != "base64:current" {
+		t.Errorf("Expected the previous key to be returned, got %q", previous)
+	}
+	rotateAppKey(env, "base64:third")
+
+	want := "APP_KEY=base64:third\nAPP_PREVIOUS_KEYS=base64:second,base64:current\nAPP_URL=http://localhost\n"
+	if got := string(env.Bytes()); got != want {
+		t.Errorf("Unexpected file after rotating:\n%s\nwant:\n%s", got, want)
+	}
+
+	empty, _ := dotenv.Parse([]byte("APP_KEY=\n"))
+	if previous := rotateAppKey(empty, "base64:first"); previous != "" || empty.Has("APP_PREVIOUS_KEYS") {
+		t.Error("Expected an empty key not to be kept")
+	}
+}
+
+func TestKeyCommands(t *testing.T) {
+	root := useEnvProject(t, "APP_KEY=\n")
+
+	if err := keyGenerateCmd.RunE(keyGenerateCmd, nil); err != nil {
+		t.Fatalf("key generate returned error: %v", err)
+	}
+	env, _ := dotenv.Load(filepath.Join(root, ".env"))
+	first := mustGet(env, "APP_KEY")
+	if key, _ := encryption.ParseKey(first); len(key) != 32 {
+		t.Fatalf("Expected a 32 byte key, got %q", first)
+	}
+
+	if err := keyGenerateCmd.RunE(keyGenerateCmd, nil); err == nil || !strings.Contains(err.Error(), "key rotate") {
+		t.Errorf("Expected an existing key not to be replaced, got %v", err)
+	}
+
+	if err := keyRotateCmd.RunE(keyRotateCmd, nil); err != nil {
+		t.Fatalf("key rotate returned error: %v", err)
+	}
+	env, _ = dotenv.Load(filepath.Join(root, ".env"))
+	if current := mustGet(env, "APP_KEY"); current == first || current == "" {
+		t.Errorf("Expected a new key, got %q", current)
+	}
+	if previous := mustGet(env, "APP_PREVIOUS_KEYS"); previous != first {
+		t.Errorf("Expected APP_PREVIOUS_KEYS = %q, got %q", first, previous)
+	}
+
+	stdout, keyShow = &bytes.Buffer{}, true
+	if err := keyGenerateCmd.RunE(keyGenerateCmd, nil); err != nil {
+		t.Fatalf("key generate --show returned error: %v", err)
+	}
+	if shown := strings.TrimSpace(stdout.(*bytes.Buffer).String()); !strings.HasPrefix(shown, "base64:") || shown == mustGet(env, "APP_KEY") {
+		t.Errorf("Expected --show to print a new key, got %q", shown)
+	}
+}
+
+func TestCreateNewProjectGeneratesAppKey(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	quiet, noInteraction = true, true
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	for _, line := range fake.commandLines() {
+		if strings.Contains(line, "key:generate") {
+			t.Errorf("Expected no artisan call for the key, got %q", line)
+		}
+	}
+	env, _ := dotenv.Load(filepath.Join("demo", ".env"))
+	if key, err := encryption.ParseKey(mustGet(env, "APP_KEY")); err != nil || len(key) != 32 {
+		t.Errorf("Expected a generated APP_KEY, got %q", mustGet(env, "APP_KEY"))
+	}
+}
+
+func mustGet(env *dotenv.File, key string) string {
+	value, _ := env.Get(key)
+	return value
+}
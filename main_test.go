@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -65,6 +66,36 @@ APP_URL=http://localhost`
 	removeTestFile(tmpFile)
 }
 
+func TestSwitchingDatabaseKeepsEnvLayout(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	// Spacing, quoting and a non-default port that literal matching used to miss
+	content := "APP_NAME=\"My App\"\r\n\r\nDB_CONNECTION=mysql # local\r\nDB_HOST=db.internal\r\nDB_PORT=3307\r\nDB_DATABASE=shop\r\n"
+	writeTestFile(envPath, content)
+
+	if err := configureDatabaseConnection(dir, "sqlite", databaseSettings{}); err != nil {
+		t.Fatalf("configureDatabaseConnection returned error: %v", err)
+	}
+	env, _ := readTestFile(envPath)
+	want := "APP_NAME=\"My App\"\r\n\r\nDB_CONNECTION=sqlite # local\r\n# DB_HOST=db.internal\r\n# DB_PORT=3307\r\n# DB_DATABASE=shop\r\n"
+	if !strings.HasPrefix(env, want) {
+		t.Errorf("Unexpected .env for SQLite:\n%q\nwant prefix:\n%q", env, want)
+	}
+
+	if err := configureDatabaseConnection(dir, "pgsql", databaseSettings{Database: "shop"}); err != nil {
+		t.Fatalf("configureDatabaseConnection returned error: %v", err)
+	}
+	env, _ = readTestFile(envPath)
+	for _, line := range []string{"DB_CONNECTION=pgsql # local\r\n", "\r\nDB_HOST=db.internal\r\n", "\r\nDB_PORT=5432\r\n", "\r\nDB_USERNAME=root\r\n"} {
+		if !strings.Contains(env, line) {
+			t.Errorf("Expected .env to contain %q, got:\n%q", line, env)
+		}
+	}
+	if strings.Contains(env, "# DB_") {
+		t.Errorf("Expected every database key to be uncommented, got:\n%q", env)
+	}
+}
+
 func TestValidateProjectName(t *testing.T) {
 	validNames := []string{"my-project", "my_project", "my.project", "MyProject123"}
 	invalidNames := []string{"my project", "my@project", "my#project"}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var pnpm, yarn, bun bool
+
+// packageManager is a JavaScript package manager the frontend dependencies
+// can be installed and built with.
+type packageManager struct {
+	Name      string
+	Flag      *bool    // The `laravel new` flag selecting it
+	LockFiles []string // Lockfiles it writes, newest format first
+	Hint      string   // Where to get it
+}
+
+// packageManagers are listed in order of preference when a project has
+// lockfiles of several of them.
+var packageManagers = []*packageManager{
+	{Name: "npm", Flag: &npm, LockFiles: []string{"package-lock.json"}, Hint: "npm is installed together with Node: https://nodejs.org/"},
+	{Name: "pnpm", Flag: &pnpm, LockFiles: []string{"pnpm-lock.yaml"}, Hint: "Install pnpm: https://pnpm.io/installation"},
+	{Name: "yarn", Flag: &yarn, LockFiles: []string{"yarn.lock"}, Hint: "Install Yarn: https://yarnpkg.com/getting-started/install"},
+	{Name: "bun", Flag: &bun, LockFiles: []string{"bun.lock", "bun.lockb"}, Hint: "Install Bun: https://bun.sh/"},
+}
+
+func lookupPackageManager(name string) *packageManager {
+	for _, manager := range packageManagers {
+		if manager.Name == name {
+			return manager
+		}
+	}
+	return nil
+}
+
+// commands returns the install and build commands.
+func (m *packageManager) commands() [][]string {
+	return [][]string{m.installCommand(), m.buildCommand()}
+}
+
+func (m *packageManager) installCommand() []string {
+	return []string{m.Name, "install"}
+}
+
+func (m *packageManager) buildCommand() []string {
+	return []string{m.Name, "run", "build"}
+}
+
+// commandLine is the install and build commands as they are typed.
+func (m *packageManager) commandLine() string {
+	return fmt.Sprintf("%s install && %s run build", m.Name, m.Name)
+}
+
+// selectedPackageManager returns the package manager chosen with --npm,
+// --pnpm, --yarn or --bun, or nil when the dependencies are not installed.
+func selectedPackageManager() *packageManager {
+	for _, manager := range packageManagers {
+		if *manager.Flag {
+			return manager
+		}
+	}
+	return nil
+}
+
+// validatePackageManagerFlags rejects more than one package manager.
+func validatePackageManagerFlags() error {
+	var given []string
+	for _, manager := range packageManagers {
+		if *manager.Flag {
+			given = append(given, "--"+manager.Name)
+		}
+	}
+	if len(given) > 1 {
+		return fmt.Errorf("%s cannot be combined: choose one package manager", strings.Join(given, " and "))
+	}
+	return nil
+}
+
+// projectPackageManager returns the package manager for the project in dir:
+// the one given as a flag, the packageManager field of package.json, the
+// one whose lockfile is present, or npm.
+func projectPackageManager(dir string) *packageManager {
+	if manager := selectedPackageManager(); manager != nil {
+		return manager
+	}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			PackageManager string `json:"packageManager"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			// e.g. "pnpm@9.12.1+sha512.f0dda8580f..."
+			name, _, _ := strings.Cut(pkg.PackageManager, "@")
+			if manager := lookupPackageManager(name); manager != nil {
+				return manager
+			}
+		}
+	}
+
+	for _, manager := range packageManagers {
+		for _, lockFile := range manager.LockFiles {
+			if _, err := os.Stat(filepath.Join(dir, lockFile)); err == nil {
+				return manager
+			}
+		}
+	}
+	return packageManagers[0]
+}
+
+// foreignLockFiles returns the lockfiles of the other package managers, which
+// would pin different versions than the ones about to be installed.
+func foreignLockFiles(dir string, manager *packageManager) []string {
+	var paths []string
+	for _, other := range packageManagers {
+		if other == manager {
+			continue
+		}
+		for _, lockFile := range other.LockFiles {
+			paths = append(paths, filepath.Join(dir, lockFile))
+		}
+	}
+	return paths
+}
+
+// installFrontendDependencies installs the frontend dependencies with the
+// package manager, after removing the lockfiles of the other ones.
+func installFrontendDependencies(ctx context.Context, projectDir string, manager *packageManager) error {
+	if !quiet {
+		fmt.Fprintf(stepStdout(ctx), "Installing the frontend dependencies with %s...\n", manager.Name)
+	}
+
+	for _, path := range foreignLockFiles(projectDir, manager) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			if err := warn(ctx, exitNpmFailed, err, "Could not remove "+filepath.Base(path)); err != nil {
+				return err
+			}
+		}
+	}
+	return runPackageManagerCommand(ctx, projectDir, manager, manager.installCommand())
+}
+
+// buildFrontend builds the frontend assets with the package manager.
+func buildFrontend(ctx context.Context, projectDir string, manager *packageManager) error {
+	if !quiet {
+		fmt.Fprintf(stepStdout(ctx), "Building the frontend with %s...\n", manager.Name)
+	}
+	return runPackageManagerCommand(ctx, projectDir, manager, manager.buildCommand())
+}
+
+func runPackageManagerCommand(ctx context.Context, projectDir string, manager *packageManager, cmdArgs []string) error {
+	if _, err := runCommand(ctx, newCommand(projectDir, cmdArgs[0], cmdArgs[1:]...)); err != nil {
+		return warn(ctx, exitNpmFailed, err, manager.Name+" command failed")
+	}
+	return nil
+}
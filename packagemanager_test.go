@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectPackageManager(t *testing.T) {
+	testCases := []struct {
+		files map[string]string
+		flag  *bool
+		want  string
+	}{
+		{nil, nil, "npm"},
+		{map[string]string{"yarn.lock": ""}, nil, "yarn"},
+		{map[string]string{"bun.lockb": "", "package-lock.json": ""}, nil, "npm"},
+		{map[string]string{"package.json": `{"packageManager": "pnpm@9.12.1+sha512.f0dda8580f"}`, "package-lock.json": ""}, nil, "pnpm"},
+		{map[string]string{"package.json": `{"packageManager": "deno@2.0.0"}`, "bun.lock": ""}, nil, "bun"},
+		{map[string]string{"yarn.lock": ""}, &bun, "bun"},
+	}
+	for _, tc := range testCases {
+		useFakeRunner(t, "")
+		dir := t.TempDir()
+		for name, data := range tc.files {
+			writeTestFile(filepath.Join(dir, name), data)
+		}
+		if tc.flag != nil {
+			*tc.flag = true
+		}
+
+		if got := projectPackageManager(dir).Name; got != tc.want {
+			t.Errorf("projectPackageManager(%v) = %s; want %s", tc.files, got, tc.want)
+		}
+	}
+}
+
+func TestPackageManagerFlag(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	fake.handler = func(cmd Command) (*Result, error) {
+		fakeSkeletonHandler(cmd)
+		if cmd.Name == "composer" && cmd.Args[0] == "create-project" {
+			writeTestFile(filepath.Join(cmd.Dir, cmd.Args[2], "package-lock.json"), "{}")
+			writeTestFile(filepath.Join(cmd.Dir, cmd.Args[2], "pnpm-lock.yaml"), "")
+		}
+		return &Result{}, nil
+	}
+	quiet, noInteraction, pnpm = true, true, true
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	commands := strings.Join(fake.commandLines(), "\n")
+	if !strings.Contains(commands, "pnpm install\npnpm run build") || contains(fake.commandLines(), "npm install") {
+		t.Errorf("Expected pnpm to install and build, got:\n%s", commands)
+	}
+	if _, err := os.Stat(filepath.Join("shop", "package-lock.json")); !os.IsNotExist(err) {
+		t.Error("Expected the npm lockfile to be removed")
+	}
+	if _, err := os.Stat(filepath.Join("shop", "pnpm-lock.yaml")); err != nil {
+		t.Error("Expected the pnpm lockfile to be kept")
+	}
+}
+
+func TestPackageManagerPromptUsesTheProjectsTool(t *testing.T) {
+	// SQLite, the default URL, no migrations, then yes to the frontend
+	fake := useFakeRunner(t, "\n\nn\ny\n")
+	fake.handler = func(cmd Command) (*Result, error) {
+		fakeSkeletonHandler(cmd)
+		if cmd.Name == "composer" && cmd.Args[0] == "create-project" {
+			writeTestFile(filepath.Join(cmd.Dir, cmd.Args[2], "bun.lock"), "")
+		}
+		return &Result{}, nil
+	}
+	var out strings.Builder
+	promptOut, quiet = &out, true
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Would you like to run bun install and bun run build?") {
+		t.Errorf("Expected the prompt to name bun, got:\n%s", out.String())
+	}
+	if !contains(fake.commandLines(), "bun install") || !contains(fake.commandLines(), "bun run build") {
+		t.Errorf("Expected bun to install and build, got:\n%s", strings.Join(fake.commandLines(), "\n"))
+	}
+}
+
+func TestPackageManagerFlagsConflict(t *testing.T) {
+	useFakeRunner(t, "")
+	npm, yarn = true, true
+
+	if err := createNewProject("shop"); exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), "--npm and --yarn cannot be combined") {
+		t.Errorf("Expected a usage error, got %v", err)
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"laravel-cli/dotenv"
+)
+
+// Plan lists everything `laravel new` would do for the current flags. It is
+// built without touching the filesystem or spawning any process.
+type Plan struct {
+	Project        string     `json:"project"`
+	Directory      string     `json:"directory"`
+	RemoveExisting bool       `json:"remove_existing"`
+	Database       string     `json:"database"`
+	StarterKit     string     `json:"starter_kit,omitempty"`
+	Preset         string     `json:"preset,omitempty"`
+	Warnings       []string   `json:"warnings,omitempty"`
+	Steps          []PlanStep `json:"steps"`
+}
+
+// PlanStep groups the commands, file operations and environment edits of a
+// single installation step.
+type PlanStep struct {
+	Name       string             `json:"name"`
+	Condition  string             `json:"condition,omitempty"`
+	Commands   []PlannedCommand   `json:"commands,omitempty"`
+	Files      []string           `json:"files,omitempty"`
+	EnvChanges []PlannedEnvChange `json:"env_changes,omitempty"`
+}
+
+// PlannedCommand is a process the installer would spawn.
+type PlannedCommand struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args"`
+	Dir     string   `json:"dir"`
+	Env     []string `json:"env,omitempty"`
+}
+
+// PlannedEnvChange is a key the installer would change in an environment file.
+type PlannedEnvChange struct {
+	File   string `json:"file"`
+	Action string `json:"action"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+}
+
+func (c PlannedCommand) String() string {
+	return Command{Name: c.Command, Args: c.Args}.String()
+}
+
+func plannedCommand(dir string, env []string, cmdArgs ...string) PlannedCommand {
+	if dir == "" {
+		dir = "."
+	}
+	return PlannedCommand{Command: cmdArgs[0], Args: cmdArgs[1:], Dir: dir, Env: env}
+}
+
+// plannedDatabaseCommand shows the databaseScript invocation with the script
+// abbreviated and the password masked.
+func plannedDatabaseCommand(projectDir string, driver *databaseDriver, settings databaseSettings) PlannedCommand {
+	if settings.URL != "" {
+		settings.applyURL(settings.URL)
+	}
+	cmd := databaseCommand(projectDir, "create", driver, settings, applicationDatabases(settings))
+	for i, env := range cmd.Env {
+		if strings.HasPrefix(env, "LARAVEL_DB_PASSWORD=") && settings.Password != "" {
+			cmd.Env[i] = "LARAVEL_DB_PASSWORD=********"
+		}
+	}
+	return plannedCommand(projectDir, cmd.Env, "php", "-r", "<create database script>")
+}
+
+func plannedCommands(dir string, env []string, commands [][]string) []PlannedCommand {
+	planned := make([]PlannedCommand, 0, len(commands))
+	for _, cmdArgs := range commands {
+		planned = append(planned, plannedCommand(dir, env, cmdArgs...))
+	}
+	return planned
+}
+
+// buildPlan mirrors createNewProject using the same command and .env helpers,
+// so the plan cannot drift from what a real run does. Questions answered by
+// the answers file are shown with their answers.
+func buildPlan(projectName string, prompt *prompter) *Plan {
+	projectDir := filepath.Join(".", projectName)
+	envPath := filepath.Join(projectDir, ".env")
+	envExamplePath := filepath.Join(projectDir, ".env.example")
+
+	plan := &Plan{
+		Project:    projectName,
+		Directory:  projectDir,
+		Database:   database,
+		StarterKit: getStarterKit(),
+	}
+	if activePreset != nil {
+		plan.Preset = activePreset.Name
+	}
+
+	for _, tool := range []string{"composer", "php"} {
+		if _, err := runner.LookPath(tool); err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s is required but not found in PATH", tool))
+		}
+	}
+
+	plan.Steps = append(plan.Steps, PlanStep{
+		Name:     "Check the required tools",
+		Commands: plannedCommands("", nil, doctorCommands(newDoctorScope())),
+	})
+
+	if force {
+		if _, err := os.Stat(projectName); err == nil {
+			plan.RemoveExisting = true
+			plan.Steps = append(plan.Steps, PlanStep{
+				Name:  "Remove existing directory",
+				Files: []string{"remove " + projectDir},
+			})
+		}
+	}
+
+	create := PlanStep{
+		Name:     "Create Laravel project",
+		Commands: []PlannedCommand{plannedCommand("", nil, append([]string{"composer"}, createProjectArgs(projectName, plan.StarterKit, getVersion())...)...)},
+	}
+	if offline {
+		pkg, version := skeletonSource(plan.StarterKit, getVersion())
+		archive := skeletonArchive(pkg, version)
+		create.Commands = nil
+		create.Files = []string{fmt.Sprintf("extract %s into %s", archive, projectDir)}
+		if plan.StarterKit != "" {
+			create.Commands = plannedCommands("", nil, starterKitScripts(projectName))
+		}
+		if _, err := os.Stat(archive); err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("%s %s is not in the offline cache", pkg, version))
+		}
+	}
+	plan.Steps = append(plan.Steps, create)
+
+	postInstall := PlanStep{
+		Name:     "Post-installation",
+		Commands: plannedCommands("", nil, postInstallCommands(projectDir)),
+	}
+	if os.PathSeparator == '/' {
+		postInstall.Files = []string{"chmod 0755 " + filepath.Join(projectDir, "artisan")}
+	}
+	plan.Steps = append(plan.Steps, postInstall)
+
+	environment := PlanStep{
+		Name:  "Environment setup",
+		Files: []string{fmt.Sprintf("copy %s to %s (if %s does not exist)", envExamplePath, envPath, envPath)},
+	}
+	interactive := prompt.interactive
+	if plan.Database == "" {
+		plan.Database = "sqlite"
+		if answer, ok := prompt.prefilled("database"); ok {
+			plan.Database = answer
+		} else if interactive {
+			environment.Condition = "the database driver is prompted for; sqlite is the default"
+		}
+	}
+	environment.EnvChanges = append(environment.EnvChanges, PlannedEnvChange{
+		File: envPath, Action: "set", Key: "APP_KEY", Value: "<generated key, if empty>",
+	})
+	// The plan never prompts: flags and answers are used, defaults otherwise.
+	// Both are validated before the plan is built.
+	settings, _ := resolveDatabaseSettings(&prompter{answers: prompt.answers}, plan.Database, projectName)
+	driver := lookupDriver(plan.Database)
+	if interactive && driver.hasServer() && databaseURL == "" {
+		condition := "the connection settings are prompted for; the defaults are shown"
+		if environment.Condition != "" {
+			condition = environment.Condition + "; " + condition
+		}
+		environment.Condition = condition
+	}
+	changes := databaseEnvChanges(plan.Database, settings)
+	for _, change := range changes {
+		value := change.Value
+		if change.Secret && value != "" {
+			value = "********"
+		}
+		environment.EnvChanges = append(environment.EnvChanges, PlannedEnvChange{
+			File: envPath, Action: change.Action, Key: change.Key, Value: value,
+		})
+	}
+	for _, change := range exampleEnvChanges(changes) {
+		environment.EnvChanges = append(environment.EnvChanges, PlannedEnvChange{
+			File: envExamplePath, Action: change.Action, Key: change.Key, Value: change.Value,
+		})
+	}
+	appURL := defaultAppURL
+	if answer, ok := prompt.prefilled("app_url"); ok {
+		appURL = answer
+	}
+	environment.EnvChanges = append(environment.EnvChanges, PlannedEnvChange{
+		File: envPath, Action: "set", Key: "APP_URL", Value: appURL,
+	})
+	plan.Steps = append(plan.Steps, environment)
+
+	if len(driver.Extensions) > 0 || len(driver.Packages) > 0 || driver.Connection != "" {
+		setup := PlanStep{Name: "Set up the " + driver.DisplayName + " driver"}
+		if len(driver.Extensions) > 0 {
+			// Missing extensions are reported, they do not stop the installation
+			setup.Commands = append(setup.Commands, plannedCommand(projectDir, nil, "php", "-m"))
+		}
+		if len(driver.Packages) > 0 && offline {
+			plan.Warnings = append(plan.Warnings, "--offline does not download packages; run composer require "+strings.Join(driver.Packages, " ")+" once online")
+		} else if len(driver.Packages) > 0 {
+			setup.Commands = append(setup.Commands, plannedCommand(projectDir, nil, append([]string{"composer", "require"}, driver.Packages...)...))
+		}
+		if driver.Connection != "" {
+			setup.Files = []string{"add the " + driver.Name + " connection to " + filepath.Join(projectDir, "config", "database.php") + " (if it is missing)"}
+		}
+		plan.Steps = append(plan.Steps, setup)
+	}
+
+	if sailEnabled() && driver.hasServer() {
+		plan.Warnings = append(plan.Warnings, "the database is created and migrated once the Sail containers run")
+	} else if driver.Name == "sqlite" {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Name:  "Create SQLite database",
+			Files: []string{"create " + sqliteDatabasePath(projectDir, settings) + " (if it does not exist)"},
+		})
+	} else if driver.canCreateDatabases() {
+		creation := PlanStep{
+			Name:     "Create database",
+			Commands: []PlannedCommand{plannedDatabaseCommand(projectDir, driver, settings)},
+		}
+		createAnswer, answered := prompt.prefilled("create_database")
+		if confirmed, _ := strconv.ParseBool(createAnswer); createDatabase || confirmed {
+			if !createDatabase {
+				creation.Condition = "if the migrations run"
+			}
+			plan.Steps = append(plan.Steps, creation)
+		} else if interactive && !answered {
+			creation.Condition = "if confirmed at the prompt before migrating"
+			plan.Steps = append(plan.Steps, creation)
+		}
+	}
+
+	migrations := PlanStep{
+		Name:     "Database migrations",
+		Commands: []PlannedCommand{plannedCommand(projectDir, nil, migrateCommand...)},
+	}
+	if sailEnabled() && driver.hasServer() {
+		// The containers are not running yet
+	} else if answer, ok := prompt.prefilled("migrate"); ok {
+		if confirmed, _ := strconv.ParseBool(answer); confirmed {
+			plan.Steps = append(plan.Steps, migrations)
+		}
+	} else if interactive {
+		migrations.Condition = "if confirmed at the prompt"
+		plan.Steps = append(plan.Steps, migrations)
+	} else {
+		plan.Warnings = append(plan.Warnings, "database migrations are skipped without interaction")
+	}
+
+	// Cache, queue, session, mail and broadcasting drivers
+	choices := givenServiceChoices(prompt)
+	if err := validateServiceDependencies(choices, driver.Name); err != nil {
+		plan.Warnings = append(plan.Warnings, err.Error())
+	}
+	services := PlanStep{Name: "Configure services"}
+	if len(choices) < len(appServices) && interactive {
+		if _, answered := prompt.prefilled("services"); !answered {
+			services.Condition = "the remaining drivers can be chosen at a prompt"
+		}
+	}
+	changes, _ = serviceEnvChanges(choices, "phpredis", func(string) (string, error) { return "<generated>", nil })
+	if usesRedis(choices) {
+		condition := "REDIS_CLIENT is predis, with predis/predis, when PHP lacks the redis extension"
+		if services.Condition != "" {
+			condition = services.Condition + "; " + condition
+		}
+		services.Condition = condition
+	}
+	for _, change := range changes {
+		value := change.Value
+		if change.Secret && value != "" {
+			value = "********"
+		}
+		services.EnvChanges = append(services.EnvChanges, PlannedEnvChange{File: envPath, Action: change.Action, Key: change.Key, Value: value})
+	}
+	for _, change := range exampleEnvChanges(changes) {
+		services.EnvChanges = append(services.EnvChanges, PlannedEnvChange{File: envExamplePath, Action: change.Action, Key: change.Key, Value: change.Value})
+	}
+	if packages := servicePackages(choices, "phpredis"); len(packages) > 0 && offline {
+		plan.Warnings = append(plan.Warnings, "--offline does not download packages; run composer require "+strings.Join(packages, " ")+" once online")
+	} else if len(packages) > 0 {
+		services.Commands = plannedCommands(projectDir, nil, [][]string{append([]string{"composer", "require"}, packages...)})
+	}
+	if len(services.EnvChanges) > 0 || services.Condition != "" {
+		plan.Steps = append(plan.Steps, services)
+	}
+
+	if sailEnabled() {
+		services := selectSailServices(driver.Name, append(append([]string{}, sailWith...), serviceSailServices(choices)...))
+		names := []string{"laravel.test"}
+		for _, s := range services {
+			names = append(names, s.Name)
+		}
+		step := PlanStep{
+			Name:  "Generate Sail environment",
+			Files: []string{"write " + filepath.Join(projectDir, sailComposeFile) + " (" + strings.Join(names, ", ") + ")"},
+		}
+		env, _ := dotenv.Parse(nil)
+		changes, err := sailEnvChanges(services, nil, driver, settings, env)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, "Sail: "+err.Error())
+		}
+		for _, change := range changes {
+			value := change.Value
+			if change.Secret && value != "" {
+				value = "********"
+			}
+			step.EnvChanges = append(step.EnvChanges, PlannedEnvChange{File: envPath, Action: change.Action, Key: change.Key, Value: value})
+		}
+		if driver.hasServer() && lookupSailService(driver.Name) == nil {
+			plan.Warnings = append(plan.Warnings, "Sail has no "+driver.DisplayName+" service")
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	if commands := presetCommands(activePreset); len(commands) > 0 {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Name:     "Apply the " + activePreset.Name + " preset",
+			Commands: plannedCommands(projectDir, nil, commands),
+		})
+	}
+
+	if pest {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Name:     "Install Pest",
+			Commands: plannedCommands(projectDir, pestEnv, pestCommands),
+		})
+	}
+
+	// The package manager of a project that does not exist yet is only
+	// known when it is given as a flag
+	manager := selectedPackageManager()
+	npmAnswer, answered := prompt.prefilled("npm")
+	if confirmed, _ := strconv.ParseBool(npmAnswer); !offline && (manager != nil || confirmed || (interactive && !answered)) {
+		if manager == nil {
+			manager = lookupPackageManager("npm")
+			plan.Warnings = append(plan.Warnings, "the frontend dependencies are installed with the package manager named by the packageManager field of package.json or by its lockfile, npm by default")
+		}
+		npmStep := PlanStep{
+			Name:     "Install and build the frontend dependencies with " + manager.Name,
+			Commands: plannedCommands(projectDir, nil, manager.commands()),
+		}
+		for _, path := range foreignLockFiles(projectDir, manager) {
+			npmStep.Files = append(npmStep.Files, "remove "+path+" if present")
+		}
+		if selectedPackageManager() == nil && !answered {
+			npmStep.Condition = "if confirmed at the prompt"
+		}
+		plan.Steps = append(plan.Steps, npmStep)
+		if pest && jobs > 1 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("Pest and the frontend dependencies are installed at the same time (--jobs=%d); the build waits for Pest", jobs))
+		}
+	}
+
+	if git || github != "" {
+		branchName := branch
+		commands := []PlannedCommand{}
+		if branchName == "" {
+			branchName = "main"
+			commands = append(commands, plannedCommand("", nil, "git", "config", "--global", "init.defaultBranch"))
+			plan.Warnings = append(plan.Warnings, "the Git branch defaults to git's init.defaultBranch setting, or main when it is unset")
+		}
+		plan.Steps = append(plan.Steps, PlanStep{
+			Name:     "Initialize Git repository",
+			Commands: append(commands, plannedCommands(projectDir, nil, gitCommands(branchName))...),
+		})
+	}
+
+	if github != "" {
+		plan.Steps = append(plan.Steps, PlanStep{
+			Name: "Create GitHub repository",
+			Commands: []PlannedCommand{
+				plannedCommand("", nil, append([]string{"gh"}, githubAuthArgs...)...),
+				plannedCommand(projectDir, githubEnv, append([]string{"gh"}, githubCreateArgs(projectName)...)...),
+			},
+		})
+	}
+
+	return plan
+}
+
+// printPlan writes the plan as human readable text or indented JSON.
+func printPlan(w io.Writer, plan *Plan, format string) error {
+	if format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plan)
+	}
+
+	fmt.Fprintf(w, "Execution plan for %s (dry run, nothing will be changed)\n\n", plan.Project)
+	fmt.Fprintf(w, "Directory:   %s\n", plan.Directory)
+	if plan.RemoveExisting {
+		fmt.Fprintf(w, "             existing directory will be removed (--force)\n")
+	}
+	fmt.Fprintf(w, "Database:    %s\n", plan.Database)
+	if plan.StarterKit != "" {
+		fmt.Fprintf(w, "Starter kit: %s\n", plan.StarterKit)
+	}
+	if plan.Preset != "" {
+		fmt.Fprintf(w, "Preset:      %s\n", plan.Preset)
+	}
+
+	for i, step := range plan.Steps {
+		fmt.Fprintf(w, "\n%d. %s", i+1, step.Name)
+		if step.Condition != "" {
+			fmt.Fprintf(w, " (%s)", step.Condition)
+		}
+		fmt.Fprintln(w)
+
+		for _, file := range step.Files {
+			fmt.Fprintf(w, "   - %s\n", file)
+		}
+		for _, cmd := range step.Commands {
+			env := ""
+			if len(cmd.Env) > 0 {
+				env = strings.Join(cmd.Env, " ") + " "
+			}
+			fmt.Fprintf(w, "   $ %s%s  [in %s]\n", env, cmd, cmd.Dir)
+		}
+		for _, change := range step.EnvChanges {
+			fmt.Fprintf(w, "   %s: %s %s=%s\n", change.File, change.Action, change.Key, change.Value)
+		}
+	}
+
+	if len(plan.Warnings) > 0 {
+		fmt.Fprintln(w, "\nNotes:")
+		for _, warning := range plan.Warnings {
+			fmt.Fprintf(w, "   - %s\n", warning)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBuildPlanDoesNotSpawnOrWrite(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	git = true
+	branch = "develop"
+	database = "pgsql"
+
+	plan := buildPlan("demo", newPrompter(nil))
+
+	if len(fake.commands) != 0 {
+		t.Errorf("Expected no commands to run, got %v", fake.commandLines())
+	}
+	if _, err := os.Stat("demo"); !os.IsNotExist(err) {
+		t.Error("Expected the project directory not to be created")
+	}
+	if plan.RemoveExisting {
+		t.Error("Expected no directory removal without --force")
+	}
+
+	// The checks run before anything is created
+	var tools []string
+	for _, cmd := range plan.Steps[0].Commands {
+		tools = append(tools, cmd.String())
+	}
+	want := "php -v,php -m,composer --version --no-ansi,git --version,git config --get user.name,git config --get user.email"
+	if strings.Join(tools, ",") != want {
+		t.Errorf("Unexpected tool checks %v", tools)
+	}
+	var driverCheck *PlannedCommand
+	for _, step := range plan.Steps {
+		if step.Name == "Set up the PostgreSQL driver" && len(step.Commands) > 0 {
+			driverCheck = &step.Commands[0]
+		}
+	}
+	if driverCheck == nil || driverCheck.String() != "php -m" || driverCheck.Dir != "demo" {
+		t.Errorf("Expected the pdo_pgsql extension to be checked in demo, got %+v", driverCheck)
+	}
+
+	var out bytes.Buffer
+	if err := printPlan(&out, plan, "text"); err != nil {
+		t.Fatalf("printPlan returned error: %v", err)
+	}
+	for _, want := range []string{
+		"composer create-project laravel/laravel demo",
+		"git branch -M develop",
+		"demo/.env: set DB_PORT=5432",
+		"demo/.env.example: set DB_DATABASE=demo",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected plan to contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestBuildPlanForceRemovesExistingDirectory(t *testing.T) {
+	useFakeRunner(t, "")
+	force = true
+	os.Mkdir("demo", 0755)
+
+	plan := buildPlan("demo", newPrompter(nil))
+	if !plan.RemoveExisting {
+		t.Error("Expected --force to remove the existing directory")
+	}
+	if _, err := os.Stat("demo"); err != nil {
+		t.Error("Expected the existing directory to be left alone")
+	}
+}
+
+func TestPrintPlanJSON(t *testing.T) {
+	useFakeRunner(t, "")
+	npm = true
+
+	var out bytes.Buffer
+	if err := printPlan(&out, buildPlan("demo", newPrompter(nil)), "json"); err != nil {
+		t.Fatalf("printPlan returned error: %v", err)
+	}
+
+	var decoded Plan
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON: %v", err)
+	}
+	if decoded.Database != "sqlite" {
+		t.Errorf("Expected default database sqlite, got %s", decoded.Database)
+	}
+
+	last := decoded.Steps[len(decoded.Steps)-1]
+	if last.Condition != "" || len(last.Commands) != 2 {
+		t.Errorf("Expected unconditional npm step, got %+v", last)
+	}
+}
@@ -0,0 +1,587 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// preset bundles `laravel new` options with extra composer packages and
+// artisan commands under a name, e.g. `laravel new shop --preset=saas`.
+//
+// Preset files live in ~/.config/laravel/presets/<name>.toml (or .yaml) and in
+// .laravel/presets/ inside a repository, so a team can share them:
+//
+//	description = "React, PostgreSQL, Pest and Cashier"
+//	packages = ["laravel/cashier"]
+//	dev-packages = []
+//	artisan = ["vendor:publish --tag=cashier-migrations"]
+//
+//	[options]
+//	react = true
+//	database = "pgsql"
+//	pest = true
+type preset struct {
+	Name        string
+	Description string
+	Source      string            // "built-in" or the file the preset was read from
+	Options     map[string]string // Flag values keyed by long flag name
+	Packages    []string          // Extra composer packages
+	DevPackages []string          // Extra composer dev packages
+	Artisan     []string          // Artisan commands run after installation
+}
+
+// builtinPresets are available without any preset files. A preset file with
+// the same name replaces the built-in one.
+var builtinPresets = []*preset{
+	{Name: "react", Description: "Laravel + React starter kit", Options: map[string]string{"react": "true"}},
+	{Name: "vue", Description: "Laravel + Vue starter kit", Options: map[string]string{"vue": "true"}},
+	{Name: "livewire", Description: "Laravel + Livewire starter kit", Options: map[string]string{"livewire": "true"}},
+	{
+		Name:        "api",
+		Description: "API only application with Sanctum and Pest",
+		Options:     map[string]string{"pest": "true"},
+		Artisan:     []string{"install:api --without-migration-prompt"},
+	},
+	{
+		Name:        "saas",
+		Description: "React, PostgreSQL, Pest, Git and Laravel Cashier",
+		Options:     map[string]string{"react": "true", "database": "pgsql", "pest": "true", "git": "true"},
+		Packages:    []string{"laravel/cashier"},
+	},
+	{
+		Name:        "package-demo",
+		Description: "Minimal SQLite application with Git for trying out a package",
+		Options:     map[string]string{"database": "sqlite", "git": "true"},
+	},
+}
+
+// starterKitFlags choose the starter kit. A preset that sets one of them
+// replaces a starter kit chosen by the config files.
+var starterKitFlags = []string{"react", "vue", "livewire", "using"}
+
+var presetNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// activePreset is the preset applied to the current `laravel new` run.
+var activePreset *preset
+
+func isPresetOption(flag *pflag.Flag) bool {
+	return isConfigurable(flag) && flag.Name != "preset"
+}
+
+// presetDirs returns the user and project preset directories, in order of
+// increasing priority.
+func presetDirs() []string {
+	dirs := []string{filepath.Join(userConfigDir(), "presets")}
+	if dir := findInParents(filepath.Join(".laravel", "presets"), isDir); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// presetFiles maps preset names to the file that defines them.
+func presetFiles() map[string]string {
+	files := map[string]string{}
+	for _, dir := range presetDirs() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".toml" && !isYAMLPath(entry.Name())) {
+				continue
+			}
+			files[strings.TrimSuffix(entry.Name(), ext)] = filepath.Join(dir, entry.Name())
+		}
+	}
+	return files
+}
+
+// presetNames lists every known preset, sorted by name.
+func presetNames() []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, p := range builtinPresets {
+		seen[p.Name] = true
+		names = append(names, p.Name)
+	}
+	for name := range presetFiles() {
+		if !seen[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadPreset finds a preset by name, preferring preset files over built-ins.
+func loadPreset(name string) (*preset, error) {
+	if path, ok := presetFiles()[name]; ok {
+		return readPresetFile(name, path)
+	}
+	for _, p := range builtinPresets {
+		if p.Name == name {
+			builtin := *p
+			builtin.Source = "built-in"
+			return &builtin, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown preset [%s]. Run `laravel presets list` to see the available presets", name)
+}
+
+// readPresetFile parses and validates a preset file.
+func readPresetFile(name, path string) (*preset, error) {
+	doc, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &preset{Name: name, Source: path, Options: map[string]string{}}
+	for _, entry := range doc.Entries {
+		fail := func(format string, args ...interface{}) error {
+			return &confError{Path: path, Line: entry.Line, Msg: fmt.Sprintf(format, args...)}
+		}
+
+		switch {
+		case entry.Key == "description":
+			if entry.IsList {
+				return nil, fail("description expects a single value")
+			}
+			p.Description = entry.Value
+		case entry.Key == "packages" || entry.Key == "dev-packages" || entry.Key == "artisan":
+			if !entry.IsList {
+				return nil, fail("%s expects a list", entry.Key)
+			}
+			switch entry.Key {
+			case "packages":
+				p.Packages = entry.List
+			case "dev-packages":
+				p.DevPackages = entry.List
+			default:
+				for _, command := range entry.List {
+					if _, err := splitArtisanCommand(command); err != nil {
+						return nil, fail("invalid artisan command %q: %v", command, err)
+					}
+				}
+				p.Artisan = entry.List
+			}
+		case strings.HasPrefix(entry.Key, "options."):
+			key := strings.TrimPrefix(entry.Key, "options.")
+			flag := newCmd.Flags().Lookup(key)
+			if !isPresetOption(flag) {
+				return nil, fail("unknown option %q", key)
+			}
+			if entry.IsList {
+				return nil, fail("%s expects a single value", key)
+			}
+			if err := validateConfigValue(flag, entry.Value); err != nil {
+				return nil, fail("invalid value %q for %s: %v", entry.Value, key, err)
+			}
+			p.Options[key] = entry.Value
+		default:
+			return nil, fail("unknown key %q", entry.Key)
+		}
+	}
+	return p, nil
+}
+
+// applyPreset fills in flags from the selected preset. Preset options win
+// over config files and LARAVEL_* variables but not over explicit flags.
+func applyPreset(flags *pflag.FlagSet) error {
+	activePreset = nil
+	if presetName == "" {
+		return nil
+	}
+
+	p, err := loadPreset(presetName)
+	if err != nil {
+		return newExitError(exitUsage, err)
+	}
+
+	// An explicit starter kit flag wins over the preset's starter kit, while
+	// the preset's starter kit replaces one that came from the config files
+	explicitKit := false
+	presetKit := false
+	for _, name := range starterKitFlags {
+		if flag := flags.Lookup(name); flag != nil && flag.Changed {
+			explicitKit = true
+		}
+		if _, ok := p.Options[name]; ok {
+			presetKit = true
+		}
+	}
+	if presetKit && !explicitKit {
+		for _, name := range starterKitFlags {
+			if flag := flags.Lookup(name); flag != nil {
+				flag.Value.Set(flag.DefValue)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(p.Options))
+	for key := range p.Options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		flag := flags.Lookup(key)
+		if flag == nil || flag.Changed || (explicitKit && contains(starterKitFlags, key)) {
+			continue
+		}
+		if err := flag.Value.Set(p.Options[key]); err != nil {
+			return exitErrorf(exitUsage, "Preset %s: invalid value %q for %s: %v", p.Name, p.Options[key], key, err)
+		}
+	}
+
+	activePreset = p
+	return nil
+}
+
+// presetCommands returns the commands that install the preset's packages and
+// run its artisan commands inside the project.
+func presetCommands(p *preset) [][]string {
+	if p == nil {
+		return nil
+	}
+
+	var commands [][]string
+	if len(p.Packages) > 0 {
+		commands = append(commands, append([]string{"composer", "require"}, p.Packages...))
+	}
+	if len(p.DevPackages) > 0 {
+		commands = append(commands, append([]string{"composer", "require", "--dev"}, p.DevPackages...))
+	}
+	for _, command := range p.Artisan {
+		// Preset files and --artisan are checked when they are read
+		cmdArgs, _ := splitArtisanCommand(command)
+		commands = append(commands, append([]string{"php", "artisan"}, cmdArgs...))
+	}
+	return commands
+}
+
+// splitArtisanCommand splits an artisan command into arguments the way a
+// POSIX shell would: single quotes keep everything, double quotes and
+// backslashes escape, e.g. `make:model "Foo Bar"` has two arguments.
+func splitArtisanCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg, quote, escaped := false, rune(0), false
+	for _, r := range command {
+		switch {
+		case escaped:
+			if quote == '"' && r != '"' && r != '\\' && r != '$' && r != '`' {
+				current.WriteRune('\\')
+			}
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	switch {
+	case escaped:
+		return nil, fmt.Errorf("it ends with a backslash")
+	case quote != 0:
+		return nil, fmt.Errorf("unbalanced %c quote", quote)
+	case inArg:
+		args = append(args, current.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("it is empty")
+	}
+	return args, nil
+}
+
+func runPresetCommands(ctx context.Context, projectDir string, p *preset) error {
+	if !quiet {
+		fmt.Fprintf(stepStdout(ctx), "Applying the %s preset...\n", p.Name)
+	}
+
+	for _, cmdArgs := range presetCommands(p) {
+		cmd := newCommand(projectDir, cmdArgs[0], cmdArgs[1:]...)
+		if _, err := runCommand(ctx, cmd); err != nil {
+			if err := warn(ctx, exitPresetFailed, err, "Preset step failed"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// presetFromFlags builds a preset from the `laravel new` flags given to
+// `laravel presets add`.
+func presetFromFlags(name string, flags *pflag.FlagSet) *preset {
+	p := &preset{
+		Name:        name,
+		Description: presetDescription,
+		Options:     map[string]string{},
+		Packages:    presetPackages,
+		DevPackages: presetDevPackages,
+		Artisan:     presetArtisan,
+	}
+	flags.VisitAll(func(flag *pflag.Flag) {
+		// Only the flags shared with `laravel new` are options
+		if flag.Changed && isPresetOption(flag) && newCmd.Flags().Lookup(flag.Name) == flag {
+			p.Options[flag.Name] = flag.Value.String()
+		}
+	})
+	return p
+}
+
+// formatPreset renders a preset as a TOML preset file.
+func formatPreset(p *preset) []byte {
+	var b strings.Builder
+	if p.Description != "" {
+		fmt.Fprintf(&b, "description = %s\n", formatConfigValue(".toml", p.Description))
+	}
+	for _, list := range []struct {
+		key    string
+		values []string
+	}{{"packages", p.Packages}, {"dev-packages", p.DevPackages}, {"artisan", p.Artisan}} {
+		if len(list.values) > 0 {
+			fmt.Fprintf(&b, "%s = %s\n", list.key, formatConfigList(list.values))
+		}
+	}
+
+	if len(p.Options) > 0 {
+		keys := make([]string, 0, len(p.Options))
+		for key := range p.Options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[options]\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", key, formatConfigValue(".toml", p.Options[key]))
+		}
+	}
+	return []byte(b.String())
+}
+
+// presetTargetDir is where `laravel presets add` and `remove` operate.
+func presetTargetDir() string {
+	if presetProject {
+		cwd, _ := os.Getwd()
+		return filepath.Join(cwd, ".laravel", "presets")
+	}
+	return filepath.Join(userConfigDir(), "presets")
+}
+
+// printPreset shows a preset the way `laravel presets show` prints it.
+func printPreset(p *preset) {
+	fmt.Fprintf(stdout, "Name:        %s\n", p.Name)
+	if p.Description != "" {
+		fmt.Fprintf(stdout, "Description: %s\n", p.Description)
+	}
+	fmt.Fprintf(stdout, "Source:      %s\n", p.Source)
+
+	if len(p.Options) > 0 {
+		keys := make([]string, 0, len(p.Options))
+		for key := range p.Options {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		fmt.Fprintln(stdout, "\nOptions:")
+		for _, key := range keys {
+			fmt.Fprintf(stdout, "  --%s=%s\n", key, p.Options[key])
+		}
+	}
+
+	if commands := presetCommands(p); len(commands) > 0 {
+		fmt.Fprintln(stdout, "\nCommands:")
+		for _, cmdArgs := range commands {
+			fmt.Fprintf(stdout, "  $ %s\n", Command{Name: cmdArgs[0], Args: cmdArgs[1:]}.String())
+		}
+	}
+}
+
+var (
+	presetDescription string
+	presetPackages    []string
+	presetDevPackages []string
+	presetArtisan     []string
+	presetFrom        string
+	presetProject     bool
+	presetForce       bool
+)
+
+var presetsCmd = &cobra.Command{
+	Use:   "presets",
+	Short: "Manage named presets for new applications",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var presetsListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List the available presets",
+	Args:         usageArgs(cobra.NoArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		for _, name := range presetNames() {
+			p, err := loadPreset(name)
+			if err != nil {
+				fmt.Fprintf(stdout, "%-16s (invalid: %v)\n", name, err)
+				continue
+			}
+			source := "built-in"
+			if p.Source != "built-in" {
+				source = "file"
+			}
+			fmt.Fprintf(stdout, "%-16s %-9s %s\n", name, source, p.Description)
+		}
+		return nil
+	},
+}
+
+var presetsShowCmd = &cobra.Command{
+	Use:          "show <name>",
+	Short:        "Show the options, packages and commands of a preset",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := loadPreset(args[0])
+		if err != nil {
+			return newExitError(exitUsage, err)
+		}
+		printPreset(p)
+		return nil
+	},
+}
+
+var presetsAddCmd = &cobra.Command{
+	Use:   "add <name> [new flags]",
+	Short: "Save a preset from `laravel new` flags or a preset file",
+	Example: "  laravel presets add shop --react --database=pgsql --pest --package=laravel/cashier\n" +
+		"  laravel presets add team --from=presets/team.toml --project",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if !presetNamePattern.MatchString(name) {
+			return exitErrorf(exitUsage, "Invalid preset name [%s]. Use lowercase letters, numbers, dashes and underscores", name)
+		}
+
+		for _, command := range presetArtisan {
+			if _, err := splitArtisanCommand(command); err != nil {
+				return exitErrorf(exitUsage, "Invalid --artisan command %q: %v", command, err)
+			}
+		}
+
+		// Files are copied as is so their comments survive
+		data, ext := formatPreset(presetFromFlags(name, cmd.Flags())), ".toml"
+		if presetFrom != "" {
+			if _, err := readPresetFile(name, presetFrom); err != nil {
+				return newExitError(exitUsage, err)
+			}
+			content, err := os.ReadFile(presetFrom)
+			if err != nil {
+				return err
+			}
+			data, ext = content, filepath.Ext(presetFrom)
+		}
+
+		dir := presetTargetDir()
+		if existing := findPresetFile(dir, name); existing != "" {
+			if !presetForce {
+				return exitErrorf(exitUsage, "Preset [%s] already exists in %s. Use --force to replace it.", name, existing)
+			}
+			if err := os.Remove(existing); err != nil {
+				return err
+			}
+		}
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name+ext)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Saved preset %s to %s\n", name, path)
+		return nil
+	},
+}
+
+var presetsRemoveCmd = &cobra.Command{
+	Use:          "remove <name>",
+	Short:        "Delete a preset file",
+	Args:         usageArgs(cobra.ExactArgs(1)),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path := findPresetFile(presetTargetDir(), args[0])
+		if path == "" {
+			for _, p := range builtinPresets {
+				if p.Name == args[0] {
+					return exitErrorf(exitUsage, "Preset [%s] is built in and cannot be removed", args[0])
+				}
+			}
+			return exitErrorf(exitUsage, "Preset [%s] not found in %s", args[0], presetTargetDir())
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Removed preset %s (%s)\n", args[0], path)
+		return nil
+	},
+}
+
+// findPresetFile returns the preset file for name in dir, if any.
+func findPresetFile(dir, name string) string {
+	for _, ext := range []string{".toml", ".yaml", ".yml"} {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func init() {
+	presetsAddCmd.Flags().StringVar(&presetDescription, "description", "", "A short description of the preset")
+	presetsAddCmd.Flags().StringArrayVar(&presetPackages, "package", nil, "An extra composer package to require (repeatable)")
+	presetsAddCmd.Flags().StringArrayVar(&presetDevPackages, "dev-package", nil, "An extra composer dev package to require (repeatable)")
+	presetsAddCmd.Flags().StringArrayVar(&presetArtisan, "artisan", nil, "An artisan command to run after installation (repeatable)")
+	presetsAddCmd.Flags().StringVar(&presetFrom, "from", "", "Copy the preset from a preset file")
+	presetsAddCmd.Flags().BoolVar(&presetForce, "force", false, "Replace an existing preset")
+	for _, cmd := range []*cobra.Command{presetsAddCmd, presetsRemoveCmd} {
+		cmd.Flags().BoolVar(&presetProject, "project", false, "Use the project presets (.laravel/presets) instead of the user presets")
+	}
+
+	presetsCmd.AddCommand(presetsListCmd, presetsShowCmd, presetsAddCmd, presetsRemoveCmd)
+	rootCmd.AddCommand(presetsCmd)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// testPresetFlags builds a flag set with the starter kit and a few other
+// `laravel new` flags bound to local variables.
+func testPresetFlags() (*pflag.FlagSet, map[string]*bool, *string) {
+	flags := pflag.NewFlagSet("new", pflag.ContinueOnError)
+	bools := map[string]*bool{}
+	for _, name := range []string{"react", "vue", "livewire", "pest", "git"} {
+		bools[name] = flags.Bool(name, false, "")
+	}
+	flags.String("using", "", "")
+	db := flags.String("database", "", "")
+	return flags, bools, db
+}
+
+func TestApplyBuiltinPreset(t *testing.T) {
+	useConfigDirs(t)
+	t.Cleanup(func() { presetName, activePreset = "", nil })
+
+	t.Run("ReplacesConfiguredStarterKit", func(t *testing.T) {
+		flags, bools, db := testPresetFlags()
+		*bools["vue"] = true // as if set by a config file
+		presetName = "saas"
+
+		if err := applyPreset(flags); err != nil {
+			t.Fatalf("applyPreset returned error: %v", err)
+		}
+		if !*bools["react"] || *bools["vue"] {
+			t.Errorf("Expected the preset starter kit to replace vue, got react=%v vue=%v", *bools["react"], *bools["vue"])
+		}
+		if *db != "pgsql" || !*bools["pest"] || !*bools["git"] {
+			t.Errorf("Expected the saas options to be applied, got database=%q pest=%v git=%v", *db, *bools["pest"], *bools["git"])
+		}
+		if activePreset == nil || activePreset.Name != "saas" {
+			t.Errorf("Expected saas to be the active preset, got %v", activePreset)
+		}
+	})
+
+	t.Run("ExplicitFlagsWin", func(t *testing.T) {
+		flags, bools, db := testPresetFlags()
+		if err := flags.Parse([]string{"--vue", "--database=mysql"}); err != nil {
+			t.Fatalf("Failed to parse flags: %v", err)
+		}
+		presetName = "saas"
+
+		if err := applyPreset(flags); err != nil {
+			t.Fatalf("applyPreset returned error: %v", err)
+		}
+		if *bools["react"] || !*bools["vue"] {
+			t.Errorf("Expected --vue to win over the preset, got react=%v vue=%v", *bools["react"], *bools["vue"])
+		}
+		if *db != "mysql" {
+			t.Errorf("Expected --database to win over the preset, got %q", *db)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		flags, _, _ := testPresetFlags()
+		presetName = "nope"
+		if err := applyPreset(flags); exitCodeFor(err) != exitUsage {
+			t.Errorf("Expected a usage error for an unknown preset, got %v", err)
+		}
+	})
+}
+
+func TestProjectPresetFile(t *testing.T) {
+	_, projectDir := useConfigDirs(t)
+	t.Cleanup(func() { presetName, activePreset = "", nil })
+
+	dir := filepath.Join(projectDir, ".laravel", "presets")
+	os.MkdirAll(dir, 0755)
+	writeTestFile(filepath.Join(dir, "api.yaml"), `description: Team API
+packages:
+  - spatie/laravel-data
+artisan:
+  - install:api --without-migration-prompt
+options:
+  database: pgsql
+`)
+
+	p, err := loadPreset("api")
+	if err != nil {
+		t.Fatalf("loadPreset returned error: %v", err)
+	}
+	if p.Description != "Team API" || !strings.HasSuffix(p.Source, "api.yaml") {
+		t.Errorf("Expected the project preset to replace the built-in one, got %+v", p)
+	}
+
+	got := make([]string, 0)
+	for _, cmdArgs := range presetCommands(p) {
+		got = append(got, strings.Join(cmdArgs, " "))
+	}
+	want := []string{"composer require spatie/laravel-data", "php artisan install:api --without-migration-prompt"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected preset commands:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSplitArtisanCommand(t *testing.T) {
+	testCases := map[string][]string{
+		"migrate --force":                  {"migrate", "--force"},
+		`make:model "Foo Bar" --migration`: {"make:model", "Foo Bar", "--migration"},
+		`tinker --execute='echo "hi";'`:    {"tinker", `--execute=echo "hi";`},
+		`about --only=environment\ cache`:  {"about", "--only=environment cache"},
+		`db:seed --class="Seeders\\Admin"`: {"db:seed", `--class=Seeders\Admin`},
+		`make:model ""`:                    {"make:model", ""},
+	}
+	for command, want := range testCases {
+		got, err := splitArtisanCommand(command)
+		if err != nil || strings.Join(got, "|") != strings.Join(want, "|") || len(got) != len(want) {
+			t.Errorf("splitArtisanCommand(%q) = %q, %v; want %q", command, got, err, want)
+		}
+	}
+	for _, command := range []string{`make:model "Foo`, "make:model 'Foo", `migrate \`, "  "} {
+		if _, err := splitArtisanCommand(command); err == nil {
+			t.Errorf("Expected %q to be rejected", command)
+		}
+	}
+}
+
+func TestReadPresetFileErrors(t *testing.T) {
+	testCases := []struct {
+		data string
+		want string
+	}{
+		{"packages = \"laravel/cashier\"\n", "preset.toml:1: packages expects a list"},
+		{"[options]\nreact = true\nforce = true\n", "preset.toml:3: unknown option \"force\""},
+		{"[options]\ndatabase = \"oracle\"\n", "preset.toml:2: invalid value \"oracle\" for database"},
+		{"artisan = []\nsteps = []\n", "preset.toml:2: unknown key \"steps\""},
+		{"artisan = ['make:model \"Foo Bar']\n", "preset.toml:1: invalid artisan command \"make:model \\\"Foo Bar\": unbalanced \" quote"},
+	}
+
+	for _, tc := range testCases {
+		path := filepath.Join(t.TempDir(), "preset.toml")
+		writeTestFile(path, tc.data)
+		_, err := readPresetFile("test", path)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("readPresetFile(%q) error = %v; want %q", tc.data, err, tc.want)
+		}
+	}
+}
+
+func TestFormatPresetRoundTrip(t *testing.T) {
+	p := &preset{
+		Name:        "shop",
+		Description: "Shop \"deluxe\"",
+		Options:     map[string]string{"react": "true", "database": "pgsql", "branch": "main"},
+		Packages:    []string{"laravel/cashier"},
+		DevPackages: []string{"laravel/telescope"},
+		Artisan:     []string{"telescope:install"},
+	}
+
+	path := filepath.Join(t.TempDir(), "shop.toml")
+	os.WriteFile(path, formatPreset(p), 0644)
+
+	got, err := readPresetFile("shop", path)
+	if err != nil {
+		t.Fatalf("readPresetFile returned error: %v", err)
+	}
+	if got.Description != p.Description || got.Options["database"] != "pgsql" || got.Options["react"] != "true" ||
+		got.Options["branch"] != "main" || got.DevPackages[0] != "laravel/telescope" || got.Artisan[0] != "telescope:install" {
+		t.Errorf("Preset did not round-trip: %+v", got)
+	}
+}
+
+func TestCreateNewProjectRunsPresetCommands(t *testing.T) {
+	fake := useFakeRunner(t, "\n\n")
+	quiet, database = true, "mysql"
+	activePreset = &preset{Name: "shop", Packages: []string{"laravel/cashier"}, Artisan: []string{"cashier:install"}}
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	commands := strings.Join(fake.commandLines(), "\n")
+	for _, want := range []string{"composer require laravel/cashier", "php artisan cashier:install"} {
+		if !strings.Contains(commands, want) {
+			t.Errorf("Expected %q to run, got:\n%s", want, commands)
+		}
+	}
+}
+
+func TestPresetFromFlags(t *testing.T) {
+	t.Cleanup(func() {
+		resetNewFlags()
+		presetDescription, presetPackages, presetProject = "", nil, false
+		presetsAddCmd.Flags().VisitAll(func(flag *pflag.Flag) { flag.Changed = false })
+	})
+
+	if err := presetsAddCmd.ParseFlags([]string{"--vue", "--database=pgsql", "--package=laravel/cashier", "--description=Shop", "--project"}); err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	p := presetFromFlags("shop", presetsAddCmd.Flags())
+	if len(p.Options) != 2 || p.Options["vue"] != "true" || p.Options["database"] != "pgsql" {
+		t.Errorf("Expected only the laravel new flags as options, got %v", p.Options)
+	}
+	if p.Description != "Shop" || len(p.Packages) != 1 || p.Packages[0] != "laravel/cashier" {
+		t.Errorf("Unexpected preset: %+v", p)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// prompter asks the user for input. Questions are identified by a key;
+// answers loaded with --answers are used instead of asking. Without
+// interaction, because of --no-interaction or because stdin is not a
+// terminal, every other question takes its default value and questions
+// without a default fail instead of blocking.
+type prompter struct {
+	reader      *bufio.Reader
+	interactive bool
+	answers     map[string]string // Pre-filled answers keyed by question
+	recorded    []confEntry       // Answers given during this run, in order
+}
+
+func newPrompter(answers map[string]string) *prompter {
+	return &prompter{
+		reader:      bufio.NewReader(stdin),
+		interactive: isInteractive(),
+		answers:     answers,
+	}
+}
+
+// prefilled returns the answer given for key in the answers file.
+func (p *prompter) prefilled(key string) (string, bool) {
+	value, ok := p.answers[key]
+	return value, ok
+}
+
+// record remembers the answer to a question for --record-answers.
+func (p *prompter) record(key, value string) {
+	for i := range p.recorded {
+		if p.recorded[i].Key == key {
+			p.recorded[i].Value = value
+			return
+		}
+	}
+	p.recorded = append(p.recorded, confEntry{Key: key, Value: value})
+}
+
+// isInteractive reports whether the installer may ask questions.
+func isInteractive() bool {
+	return !noInteraction && isTerminal(stdin)
+}
+
+// isTerminal reports whether r is a character device such as a terminal.
+// Readers that are not files, like scripted input, count as interactive.
+func isTerminal(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return true
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// readLine reads one line of input. Once input is exhausted the prompter
+// stops being interactive, so callers fall back to their defaults instead of
+// asking forever.
+func (p *prompter) readLine() (string, error) {
+	input, err := p.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || input == "") {
+		p.interactive = false
+		return "", err
+	}
+	return strings.TrimSpace(input), nil
+}
+
+// readSecret reads one line like readLine, with the echo turned off when
+// stdin is a terminal.
+func (p *prompter) readSecret() (string, error) {
+	file, ok := stdin.(*os.File)
+	if !ok || !isTerminal(file) {
+		return p.readLine()
+	}
+	restore, err := disableEcho(file)
+	if err != nil {
+		return p.readLine()
+	}
+	terminalMu.Lock()
+	terminalRestore = restore
+	terminalMu.Unlock()
+	defer restoreTerminal()
+
+	input, err := p.readLine()
+	if err == nil {
+		fmt.Fprintln(promptOut) // Enter was not echoed either
+	}
+	return input, err
+}
+
+// terminalRestore turns the echo back on while a secret is being read.
+var (
+	terminalMu      sync.Mutex
+	terminalRestore func()
+)
+
+// restoreTerminal turns the echo back on if a secret is being read, also
+// when the installer exits from the signal handler in the middle of it.
+func restoreTerminal() {
+	terminalMu.Lock()
+	defer terminalMu.Unlock()
+	if terminalRestore != nil {
+		terminalRestore()
+		terminalRestore = nil
+	}
+}
+
+// missingValueError is returned when a value without a default is needed
+// but cannot be asked for.
+func missingValueError(name, flag string) error {
+	if flag != "" {
+		return exitErrorf(exitUsage, "%s is required. Pass it with --%s when running without interaction", name, flag)
+	}
+	return exitErrorf(exitUsage, "%s is required but cannot be asked for without interaction", name)
+}
+
+// askForRequiredString asks for a value that has no default. The flag name
+// is mentioned in the error when the value cannot be asked for.
+func askForRequiredString(p *prompter, key, name, flag string) (string, error) {
+	if value, ok := p.prefilled(key); ok {
+		p.record(key, value)
+		return value, nil
+	}
+	if !p.interactive {
+		return "", missingValueError(name, flag)
+	}
+
+	for {
+		fmt.Fprintf(promptOut, "%s: ", name)
+		input, err := p.readLine()
+		if err != nil {
+			return "", missingValueError(name, flag)
+		}
+		if input != "" {
+			p.record(key, input)
+			return input, nil
+		}
+		fmt.Fprintf(promptOut, "%s cannot be empty.\n", name)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if isTerminal(r) {
+		t.Error("Expected a pipe not to be a terminal")
+	}
+	if !isTerminal(strings.NewReader("")) {
+		t.Error("Expected scripted input to count as interactive")
+	}
+}
+
+func TestNonInteractivePromptsUseDefaults(t *testing.T) {
+	p := &prompter{reader: bufio.NewReader(strings.NewReader("4\nhttp://example.test\ny\n")), interactive: false}
+
+	if got := promptForDatabase(p); got != "sqlite" {
+		t.Errorf("promptForDatabase() = %q; want sqlite", got)
+	}
+	if got := askForString(p, "app_url", "App URL", defaultAppURL); got != defaultAppURL {
+		t.Errorf("askForString() = %q; want %q", got, defaultAppURL)
+	}
+	if askForConfirmation(p, "migrate", "Run migrations?") {
+		t.Error("Expected askForConfirmation to default to no")
+	}
+
+	_, err := askForRequiredString(p, "db_password", "Database password", "db-password")
+	if exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), "--db-password") {
+		t.Errorf("Expected a usage error naming the flag, got %v", err)
+	}
+}
+
+func TestPromptsStopAtEndOfInput(t *testing.T) {
+	p := &prompter{reader: bufio.NewReader(strings.NewReader("")), interactive: true}
+
+	// Used to spin forever on a closed stdin
+	if got := promptForDatabase(p); got != "sqlite" {
+		t.Errorf("promptForDatabase() = %q; want sqlite", got)
+	}
+	if p.interactive {
+		t.Error("Expected the prompter to stop being interactive at the end of input")
+	}
+	if _, err := askForRequiredString(p, "db_database", "Database name", ""); err == nil {
+		t.Error("Expected a required value to fail once input is exhausted")
+	}
+}
+
+func TestCreateNewProjectWithoutInteraction(t *testing.T) {
+	fake := useFakeRunner(t, "2\nhttp://example.test\ny\ny\n")
+	quiet, noInteraction = true, true
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	for _, line := range fake.commandLines() {
+		if strings.Contains(line, "migrate") || strings.HasPrefix(line, "npm") {
+			t.Errorf("Expected no prompted steps to run, got %q", line)
+		}
+	}
+
+	env, _ := os.ReadFile(filepath.Join("demo", ".env"))
+	for _, line := range []string{"DB_CONNECTION=sqlite", "APP_URL=" + defaultAppURL} {
+		if !strings.Contains(string(env), line) {
+			t.Errorf("Expected .env to contain %s", line)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("demo", "database", "database.sqlite")); err != nil {
+		t.Errorf("Expected the SQLite database to be created: %v", err)
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	// A pipe is not a terminal, so the line is read as is
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	oldStdin := stdin
+	stdin = r
+	t.Cleanup(func() { stdin = oldStdin })
+
+	w.WriteString("s3cret\n")
+	w.Close()
+	p := &prompter{reader: bufio.NewReader(r), interactive: true}
+	if got, err := p.readSecret(); err != nil || got != "s3cret" {
+		t.Errorf("readSecret() = %q, %v; want s3cret", got, err)
+	}
+
+	// The echo is turned back on once, by whoever gets there first
+	restored := 0
+	terminalRestore = func() { restored++ }
+	restoreTerminal()
+	restoreTerminal()
+	if restored != 1 {
+		t.Errorf("Expected the terminal to be restored once, got %d", restored)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// transaction records everything a `laravel new` run creates so that it can
+// be undone when the installation fails or is interrupted.
+type transaction struct {
+	mu      sync.Mutex
+	actions []undoAction
+	done    bool
+}
+
+type undoAction struct {
+	description string
+	undo        func() error
+}
+
+// record registers an undo action. Actions are undone in reverse order.
+func (tx *transaction) record(description string, undo func() error) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.actions = append(tx.actions, undoAction{description: description, undo: undo})
+}
+
+// recordPath registers a file or directory that should be removed on rollback.
+func (tx *transaction) recordPath(description, path string) {
+	tx.record(description, func() error {
+		return os.RemoveAll(path)
+	})
+}
+
+// rollback undoes every recorded action, most recent first. It only runs
+// once; later calls are no-ops.
+func (tx *transaction) rollback() []error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+
+	var errs []error
+	for i := len(tx.actions) - 1; i >= 0; i-- {
+		action := tx.actions[i]
+		if !quiet {
+			fmt.Printf("Rolling back: %s\n", action.description)
+		}
+		if err := action.undo(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", action.description, err))
+		}
+	}
+	return errs
+}
+
+// commit marks the transaction as finished so nothing is rolled back.
+func (tx *transaction) commit() {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.done = true
+}
+
+// abort rolls back a failed installation unless --keep-on-failure is set.
+func (tx *transaction) abort(projectDir string) {
+	if keepOnFailure {
+		if !quiet {
+			fmt.Printf("Keeping the partially installed project in %s (--keep-on-failure)\n", projectDir)
+		}
+		tx.commit()
+		return
+	}
+
+	for _, err := range tx.rollback() {
+		printWarning(context.Background(), fmt.Sprintf("Rollback step failed: %v", err))
+	}
+}
+
+// recordGitHubRepository registers deletion of a freshly created GitHub
+// repository. gh needs the delete_repo scope for this to succeed.
+func (tx *transaction) recordGitHubRepository(repoName string) {
+	tx.record("delete GitHub repository "+repoName, func() error {
+		cmd := Command{Name: "gh", Args: []string{"repo", "delete", repoName, "--yes"}, Timeout: time.Minute}
+		if _, err := runCommand(context.Background(), cmd); err != nil {
+			return fmt.Errorf("%w (delete it manually with: gh repo delete %s)", err, repoName)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransactionRollbackOrder(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+
+	var order []string
+	tx := &transaction{}
+	tx.record("first", func() error { order = append(order, "first"); return nil })
+	tx.record("second", func() error { order = append(order, "second"); return errors.New("boom") })
+	tx.record("third", func() error { order = append(order, "third"); return nil })
+
+	errs := tx.rollback()
+	if strings.Join(order, ",") != "third,second,first" {
+		t.Errorf("Expected reverse order, got %v", order)
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "second") {
+		t.Errorf("Expected one error from the second action, got %v", errs)
+	}
+
+	// A second rollback must not undo anything again
+	order = nil
+	tx.rollback()
+	if len(order) != 0 {
+		t.Errorf("Expected rollback to run once, got %v", order)
+	}
+}
+
+func TestTransactionAbortRemovesCreatedFiles(t *testing.T) {
+	quiet = true
+	defer func() { quiet = false }()
+
+	dir := filepath.Join(t.TempDir(), "demo")
+	os.MkdirAll(filepath.Join(dir, ".git"), 0755)
+
+	tx := &transaction{}
+	tx.recordPath("remove project directory", dir)
+	tx.recordPath("remove Git repository", filepath.Join(dir, ".git"))
+	tx.abort(dir)
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("Expected the project directory to be removed")
+	}
+}
+
+func TestTransactionAbortKeepOnFailure(t *testing.T) {
+	quiet = true
+	keepOnFailure = true
+	defer func() { quiet, keepOnFailure = false, false }()
+
+	dir := filepath.Join(t.TempDir(), "demo")
+	os.MkdirAll(dir, 0755)
+
+	tx := &transaction{}
+	tx.recordPath("remove project directory", dir)
+	tx.abort(dir)
+
+	if _, err := os.Stat(dir); err != nil {
+		t.Error("Expected --keep-on-failure to keep the project directory")
+	}
+}
+
+func TestTransactionCommitSkipsRollback(t *testing.T) {
+	called := false
+	tx := &transaction{}
+	tx.record("undo", func() error { called = true; return nil })
+	tx.commit()
+	tx.rollback()
+
+	if called {
+		t.Error("Expected a committed transaction not to roll back")
+	}
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Command describes a single external process invocation.
+type Command struct {
+	Name    string
+	Args    []string
+	Dir     string
+	Env     []string      // Extra KEY=VALUE pairs appended to the current environment
+	Timeout time.Duration // Zero means no timeout
+	Stdout  io.Writer     // Live output is teed here in addition to being captured
+	Stderr  io.Writer
+}
+
+// String renders the command the way a user would type it in a shell.
+func (c Command) String() string {
+	parts := make([]string, 0, len(c.Args)+1)
+	parts = append(parts, shellQuote(c.Name))
+	for _, arg := range c.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Result holds the outcome of a finished command.
+type Result struct {
+	ExitCode int
+	Stdout   []byte
+	Stderr   []byte
+	Duration time.Duration
+}
+
+// CommandError is returned when a command cannot be started, exits with a
+// non-zero status or runs past its timeout.
+type CommandError struct {
+	Command  Command
+	ExitCode int
+	TimedOut bool
+	Stderr   string
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	switch {
+	case e.TimedOut:
+		return fmt.Sprintf("%s timed out after %s", e.Command, e.Command.Timeout)
+	case e.ExitCode > 0:
+		return fmt.Sprintf("%s exited with status %d", e.Command, e.ExitCode)
+	default:
+		return fmt.Sprintf("%s: %v", e.Command, e.Err)
+	}
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Runner executes external commands. Every composer, php, git, gh and npm
+// invocation goes through the package-level runner so it can be swapped out
+// when the installer is embedded or tested.
+type Runner interface {
+	Run(ctx context.Context, cmd Command) (*Result, error)
+	LookPath(name string) (string, error)
+}
+
+// ExecRunner runs commands as real child processes.
+type ExecRunner struct {
+	// BinDir, when set, is searched for executables before PATH. It lets
+	// tests point the installer at fake composer/php/git/npm binaries.
+	BinDir string
+
+	// GracePeriod is how long a child gets to exit after the interrupt has
+	// been forwarded before its process group is killed. Zero uses the
+	// package default.
+	GracePeriod time.Duration
+}
+
+var runner Runner = &ExecRunner{}
+
+// LookPath resolves an executable, preferring BinDir when it is set.
+func (r *ExecRunner) LookPath(name string) (string, error) {
+	if r.BinDir != "" {
+		if path, err := exec.LookPath(filepath.Join(r.BinDir, name)); err == nil {
+			return path, nil
+		}
+	}
+	return exec.LookPath(name)
+}
+
+// Run starts the command, waits for it to finish and reports its exit status.
+// When ctx is cancelled the signal that caused it is forwarded to the child's
+// process group, which is killed if it is still running after the grace period.
+func (r *ExecRunner) Run(ctx context.Context, c Command) (*Result, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	path, err := r.LookPath(c.Name)
+	if err != nil {
+		return &Result{ExitCode: -1}, &CommandError{Command: c, ExitCode: -1, Err: err}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, c.Args...)
+	cmd.Dir = c.Dir
+	cmd.Env = commandEnv(r.BinDir, c.Env)
+	cmd.Stdout = teeWriter(&stdout, c.Stdout)
+	cmd.Stderr = teeWriter(&stderr, c.Stderr)
+
+	grace := r.GracePeriod
+	if grace == 0 {
+		grace = gracePeriod
+	}
+	exited := make(chan struct{})
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		err := signalProcessGroup(cmd, interruptSignal(ctx))
+		go func() {
+			select {
+			case <-exited:
+			case <-time.After(grace):
+				killProcessGroup(cmd)
+			}
+		}()
+		return err
+	}
+	cmd.WaitDelay = 2 * grace
+
+	start := time.Now()
+	err = cmd.Run()
+	close(exited)
+	result := &Result{
+		ExitCode: exitCode(cmd.ProcessState),
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		Duration: time.Since(start),
+	}
+
+	if err == nil {
+		return result, nil
+	}
+
+	cmdErr := &CommandError{
+		Command:  c,
+		ExitCode: result.ExitCode,
+		Stderr:   strings.TrimSpace(stderr.String()),
+		Err:      err,
+	}
+	if c.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		cmdErr.TimedOut = true
+	} else if isInterrupted(ctx) {
+		cmdErr.Err = context.Cause(ctx)
+	}
+	return result, cmdErr
+}
+
+// commandEnv builds the child environment, putting binDir first on PATH so
+// that commands spawned by the child resolve the same binaries.
+func commandEnv(binDir string, extra []string) []string {
+	env := os.Environ()
+	if binDir != "" {
+		env = append(env, "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	return append(env, extra...)
+}
+
+func teeWriter(capture *bytes.Buffer, live io.Writer) io.Writer {
+	if live == nil {
+		return capture
+	}
+	return io.MultiWriter(capture, live)
+}
+
+func exitCode(state *os.ProcessState) int {
+	if state == nil {
+		return -1
+	}
+	return state.ExitCode()
+}
+
+// runCommand runs cmd through the package-level runner and records it
+// against the installation step in progress. The live output of a step that
+// runs alongside others goes through that step's prefixed writers.
+func runCommand(ctx context.Context, cmd Command) (*Result, error) {
+	if rec := currentStep(ctx); rec != nil {
+		rec.record(cmd)
+	}
+	if output, ok := ctx.Value(stepOutputKey{}).(*stepOutput); ok {
+		if cmd.Stdout != nil {
+			cmd.Stdout = output.stdout
+		}
+		if cmd.Stderr != nil {
+			cmd.Stderr = output.stderr
+		}
+	}
+	return runner.Run(ctx, cmd)
+}
+
+// newCommand builds a Command that streams its output to the terminal
+// unless quiet mode is enabled.
+func newCommand(dir, name string, args ...string) Command {
+	cmd := Command{Name: name, Args: args, Dir: dir}
+	if offline && name == "composer" {
+		// Fail at once instead of waiting for a network that is not there
+		cmd.Env = []string{"COMPOSER_DISABLE_NETWORK=1"}
+	}
+	if !quiet {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	return cmd
+}
+
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if strings.IndexFunc(s, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r == '/' || r == ':' || r == '=' || r == '@' || r == ',' || r == '+' ||
+			(r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9'))
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
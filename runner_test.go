@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestHelperProcess is not a real test. It is re-executed by the runner tests
+// as a stand-in child process.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("LARAVEL_CLI_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	switch os.Getenv("HELPER_MODE") {
+	case "echo":
+		fmt.Fprint(os.Stdout, "out:"+os.Getenv("HELPER_VALUE"))
+		fmt.Fprint(os.Stderr, "err")
+	case "pwd":
+		dir, _ := os.Getwd()
+		fmt.Fprint(os.Stdout, dir)
+	case "fail":
+		fmt.Fprint(os.Stderr, "boom")
+		os.Exit(3)
+	case "sleep":
+		time.Sleep(10 * time.Second)
+	case "ignore-interrupt":
+		signal.Ignore(os.Interrupt)
+		fmt.Fprintln(os.Stdout, "ready")
+		time.Sleep(10 * time.Second)
+	}
+	os.Exit(0)
+}
+
+func helperCommand(mode string) Command {
+	return Command{
+		Name: os.Args[0],
+		Args: []string{"-test.run=TestHelperProcess"},
+		Env:  []string{"LARAVEL_CLI_HELPER_PROCESS=1", "HELPER_MODE=" + mode},
+	}
+}
+
+func TestExecRunnerCapturesAndTeesOutput(t *testing.T) {
+	var live bytes.Buffer
+	cmd := helperCommand("echo")
+	cmd.Env = append(cmd.Env, "HELPER_VALUE=hello")
+	cmd.Stdout = &live
+
+	result, err := (&ExecRunner{}).Run(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if string(result.Stdout) != "out:hello" {
+		t.Errorf("Expected captured stdout 'out:hello', got %q", result.Stdout)
+	}
+	if string(result.Stderr) != "err" {
+		t.Errorf("Expected captured stderr 'err', got %q", result.Stderr)
+	}
+	if live.String() != "out:hello" {
+		t.Errorf("Expected stdout to be teed, got %q", live.String())
+	}
+}
+
+func TestExecRunnerWorkingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	cmd := helperCommand("pwd")
+	cmd.Dir = dir
+
+	result, err := (&ExecRunner{}).Run(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	want, _ := filepath.EvalSymlinks(dir)
+	got, _ := filepath.EvalSymlinks(string(result.Stdout))
+	if got != want {
+		t.Errorf("Expected working directory %s, got %s", want, got)
+	}
+}
+
+func TestExecRunnerExitStatus(t *testing.T) {
+	result, err := (&ExecRunner{}).Run(context.Background(), helperCommand("fail"))
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected *CommandError, got %v", err)
+	}
+	if cmdErr.ExitCode != 3 || result.ExitCode != 3 {
+		t.Errorf("Expected exit status 3, got %d", cmdErr.ExitCode)
+	}
+	if cmdErr.Stderr != "boom" {
+		t.Errorf("Expected stderr 'boom', got %q", cmdErr.Stderr)
+	}
+}
+
+func TestExecRunnerTimeout(t *testing.T) {
+	cmd := helperCommand("sleep")
+	cmd.Timeout = 200 * time.Millisecond
+
+	_, err := (&ExecRunner{}).Run(context.Background(), cmd)
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) || !cmdErr.TimedOut {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+}
+
+func TestExecRunnerMissingBinary(t *testing.T) {
+	_, err := (&ExecRunner{}).Run(context.Background(), Command{Name: "laravel-cli-no-such-binary"})
+	if err == nil {
+		t.Fatal("Expected an error for a missing binary")
+	}
+}
+
+func TestCommandString(t *testing.T) {
+	cmd := Command{Name: "git", Args: []string{"commit", "-q", "-m", "Set up a fresh Laravel app"}}
+	want := "git commit -q -m 'Set up a fresh Laravel app'"
+	if got := cmd.String(); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// stubScript is installed as composer, php, git and npm by
+// TestExecRunnerWithStubBinaries. It logs its name, working directory,
+// arguments, a variable from the environment and where the next tool
+// resolves on PATH, and fakes just enough output for the preflight checks.
+const stubScript = `#!/bin/sh
+name=$(basename "$0")
+echo "$name|$(pwd)|$*|$STUB_VALUE|$(command -v composer)" >> "$STUB_LOG"
+case "$name $1" in
+"php -v") echo "PHP 8.3.12 (cli)" ;;
+"php -m") printf '[PHP Modules]\n%s\n' ctype curl dom fileinfo filter hash mbstring openssl pcre pdo pdo_sqlite session tokenizer xml ;;
+"composer --version") echo "Composer version 2.8.1" ;;
+"git --version") echo "git version 2.46.0" ;;
+"git config") echo "Taylor" ;;
+"composer create-project")
+	mkdir -p "$3/database"
+	echo "<?php" > "$3/artisan"
+	printf 'APP_NAME=Laravel\nAPP_KEY=\nAPP_URL=http://localhost\nDB_CONNECTION=sqlite\n' > "$3/.env.example"
+	;;
+esac
+`
+
+func TestExecRunnerWithStubBinaries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the stubs are shell scripts")
+	}
+	useFakeRunner(t, "")
+	binDir := t.TempDir()
+	for _, name := range []string{"composer", "php", "git", "npm"} {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(stubScript), 0755); err != nil {
+			t.Fatalf("Failed to write the %s stub: %v", name, err)
+		}
+	}
+	logPath := filepath.Join(t.TempDir(), "stub.log")
+	t.Setenv("STUB_LOG", logPath)
+	runner = &ExecRunner{BinDir: binDir}
+
+	// A command on its own, with extra environment and a working directory
+	dir := t.TempDir()
+	cmd := newCommand(dir, "npm", "run", "build")
+	cmd.Env = []string{"STUB_VALUE=from-env"}
+	if _, err := runCommand(context.Background(), cmd); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	// And a whole installation
+	quiet, noInteraction, git, branch = true, true, true, "main"
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Expected the stubs to run: %v", err)
+	}
+	cwd, _ := os.Getwd()
+	cwd, _ = filepath.EvalSymlinks(cwd)
+	dir, _ = filepath.EvalSymlinks(dir)
+	composer := filepath.Join(binDir, "composer")
+	for _, want := range []string{
+		"npm|" + dir + "|run build|from-env|" + composer,
+		"php|" + cwd + "|-v||" + composer,
+		"composer|" + cwd + "|create-project laravel/laravel demo --remove-vcs --prefer-dist --no-scripts||" + composer,
+		"composer|" + cwd + "|run post-root-package-install -d demo||" + composer,
+		"git|" + filepath.Join(cwd, "demo") + "|init -q||" + composer,
+		"git|" + filepath.Join(cwd, "demo") + "|commit -q -m Set up a fresh Laravel app||" + composer,
+	} {
+		if !strings.Contains(string(data), want+"\n") {
+			t.Errorf("Expected the stubs to log %q, got:\n%s", want, data)
+		}
+	}
+	if env, _ := readTestFile(filepath.Join("demo", ".env")); !strings.Contains(env, "APP_KEY=base64:") {
+		t.Errorf("Expected .env with an application key, got:\n%s", env)
+	}
+}
+
+// fakeRunner records every command and lets tests simulate the side effects
+// of composer, php, git and npm.
+type fakeRunner struct {
+	mu       sync.Mutex
+	commands []Command
+	handler  func(cmd Command) (*Result, error)
+}
+
+func (f *fakeRunner) Run(ctx context.Context, cmd Command) (*Result, error) {
+	f.mu.Lock()
+	f.commands = append(f.commands, cmd)
+	f.mu.Unlock()
+
+	if f.handler != nil {
+		return f.handler(cmd)
+	}
+	return &Result{}, nil
+}
+
+func (f *fakeRunner) LookPath(name string) (string, error) {
+	return "/usr/bin/" + name, nil
+}
+
+func (f *fakeRunner) commandLines() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	lines := make([]string, len(f.commands))
+	for i, cmd := range f.commands {
+		lines[i] = cmd.String()
+	}
+	return lines
+}
+
+// fakeSkeletonHandler simulates `composer create-project` by writing a
+// minimal Laravel skeleton.
+func fakeSkeletonHandler(cmd Command) (*Result, error) {
+	if cmd.Name == "composer" && len(cmd.Args) > 2 && cmd.Args[0] == "create-project" {
+		dir := filepath.Join(cmd.Dir, cmd.Args[2])
+		os.MkdirAll(filepath.Join(dir, "database"), 0755)
+		os.WriteFile(filepath.Join(dir, "artisan"), []byte("<?php\n"), 0644)
+		os.WriteFile(filepath.Join(dir, ".env.example"), []byte(testEnvExample), 0644)
+	}
+	return &Result{}, nil
+}
+
+const testEnvExample = `APP_NAME=Laravel
+APP_ENV=local
+APP_KEY=
+APP_URL=http://localhost
+
+DB_CONNECTION=sqlite
+# DB_HOST=127.0.0.1
+# DB_PORT=3306
+# DB_DATABASE=laravel
+# DB_USERNAME=root
+# DB_PASSWORD=
+`
+
+// useFakeRunner swaps in a fake runner, a scripted stdin and a temporary
+// working directory for the duration of a test.
+func useFakeRunner(t *testing.T, input string) *fakeRunner {
+	t.Helper()
+
+	resetNewFlags()
+	fake := &fakeRunner{handler: fakeSkeletonHandler}
+	oldRunner, oldStdin := runner, stdin
+	runner, stdin = fake, strings.NewReader(input)
+
+	oldDir, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	t.Cleanup(func() {
+		runner, stdin = oldRunner, oldStdin
+		os.Chdir(oldDir)
+		resetNewFlags()
+	})
+	return fake
+}
+
+func resetNewFlags() {
+	dev, git, branch, github, organization, database = false, false, "", "", "", ""
+	react, vue, livewire, livewireClassComponents, workos = false, false, false, false, false
+	pest, phpunit, npm, using, force, quiet = false, false, false, "", false, false
+	pnpm, yarn, bun, jobs, offline = false, false, false, 2, false
+	dryRun, outputFormat, keepOnFailure, strict = false, "text", false, false
+	presetName, activePreset, noInteraction = "", nil, false
+	answersFile, recordAnswers = "", ""
+	databaseURL, dbHost, dbPort, dbSocket, dbDatabase, dbUsername, dbPassword = "", "", "", "", "", "", ""
+	generateDBPassword, createDatabase, testDatabases = false, false, nil
+	registeredDrivers = copyDrivers(builtinDrivers)
+	sail, sailWith = false, nil
+	cacheStore, queueConnection, sessionDriver, mailMailer, broadcastConnection = "", "", "", "", ""
+	promptOut, stdout = os.Stdout, os.Stdout
+}
+
+func TestCreateNewProjectWithFakeRunner(t *testing.T) {
+	fake := useFakeRunner(t, "\n\n")
+	quiet = true
+	git = true
+	branch = "main"
+	database = "mysql"
+	pest = true
+
+	if err := createNewProject("demo"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	want := []string{
+		"php -v",
+		"php -m",
+		"composer --version --no-ansi",
+		"git --version",
+		"git config --get user.name",
+		"git config --get user.email",
+		"composer create-project laravel/laravel demo --remove-vcs --prefer-dist --no-scripts",
+		"composer run post-root-package-install -d demo",
+		"php -m",
+		"composer remove phpunit/phpunit --dev --no-update",
+		"composer require pestphp/pest pestphp/pest-plugin-laravel --no-update --dev",
+		"composer update",
+		"php ./vendor/bin/pest --init",
+		"git init -q",
+		"git add .",
+		"git commit -q -m 'Set up a fresh Laravel app'",
+		"git branch -M main",
+	}
+	got := fake.commandLines()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected command sequence:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	env, err := os.ReadFile(filepath.Join("demo", ".env"))
+	if err != nil {
+		t.Fatalf("Expected .env to be created: %v", err)
+	}
+	for _, line := range []string{"DB_CONNECTION=mysql", "DB_HOST=127.0.0.1", "DB_DATABASE=demo", "APP_URL=http://localhost:8000"} {
+		if !strings.Contains(string(env), line) {
+			t.Errorf("Expected .env to contain %s", line)
+		}
+	}
+}
@@ -0,0 +1,728 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"laravel-cli/dotenv"
+)
+
+// sailRuntime is the PHP runtime of the application container, shipped with
+// the laravel/sail package the skeleton requires.
+const sailRuntime = "8.4"
+
+// sailComposeFile is the file Sail reads the services from.
+const sailComposeFile = "docker-compose.yml"
+
+var (
+	sail     bool
+	sailWith []string
+)
+
+// sailPort is a container port published on the host. The host port can be
+// changed with the Env variable.
+type sailPort struct {
+	Env       string
+	Default   int
+	Container int
+}
+
+// sailService is a container Sail can run next to the application.
+type sailService struct {
+	Name        string
+	Image       string
+	Command     string
+	Ports       []sailPort
+	Environment [][2]string // Container environment, in order
+	DataDir     string      // Kept in the sail-<name> volume
+	Healthcheck string      // A YAML flow sequence
+
+	// Database services are configured through the driver of the same name;
+	// the other services set Env in .env.
+	Database bool
+	Env      []envChange
+}
+
+// sailServices are the services --with and sail:add accept.
+var sailServices = []*sailService{
+	{
+		Name:  "mysql",
+		Image: "mysql/mysql-server:8.0",
+		Ports: []sailPort{{"FORWARD_DB_PORT", 3306, 3306}},
+		Environment: [][2]string{
+			{"MYSQL_ROOT_PASSWORD", "${DB_PASSWORD}"},
+			{"MYSQL_ROOT_HOST", "%"},
+			{"MYSQL_DATABASE", "${DB_DATABASE}"},
+			{"MYSQL_USER", "${DB_USERNAME}"},
+			{"MYSQL_PASSWORD", "${DB_PASSWORD}"},
+			{"MYSQL_ALLOW_EMPTY_PASSWORD", "1"},
+		},
+		DataDir:     "/var/lib/mysql",
+		Healthcheck: `["CMD", "mysqladmin", "ping", "-p${DB_PASSWORD}"]`,
+		Database:    true,
+	},
+	{
+		Name:  "mariadb",
+		Image: "mariadb:11",
+		Ports: []sailPort{{"FORWARD_DB_PORT", 3306, 3306}},
+		Environment: [][2]string{
+			{"MYSQL_ROOT_PASSWORD", "${DB_PASSWORD}"},
+			{"MYSQL_ROOT_HOST", "%"},
+			{"MYSQL_DATABASE", "${DB_DATABASE}"},
+			{"MYSQL_USER", "${DB_USERNAME}"},
+			{"MYSQL_PASSWORD", "${DB_PASSWORD}"},
+			{"MYSQL_ALLOW_EMPTY_PASSWORD", "yes"},
+		},
+		DataDir:     "/var/lib/mysql",
+		Healthcheck: `["CMD", "healthcheck.sh", "--connect", "--innodb_initialized"]`,
+		Database:    true,
+	},
+	{
+		Name:  "pgsql",
+		Image: "postgres:17",
+		Ports: []sailPort{{"FORWARD_DB_PORT", 5432, 5432}},
+		Environment: [][2]string{
+			{"PGPASSWORD", "${DB_PASSWORD:-secret}"},
+			{"POSTGRES_DB", "${DB_DATABASE}"},
+			{"POSTGRES_USER", "${DB_USERNAME}"},
+			{"POSTGRES_PASSWORD", "${DB_PASSWORD:-secret}"},
+		},
+		DataDir:     "/var/lib/postgresql/data",
+		Healthcheck: `["CMD", "pg_isready", "-q", "-d", "${DB_DATABASE}", "-U", "${DB_USERNAME}"]`,
+		Database:    true,
+	},
+	{
+		Name:        "mongodb",
+		Image:       "mongo:7",
+		Ports:       []sailPort{{"FORWARD_MONGODB_PORT", 27017, 27017}},
+		DataDir:     "/data/db",
+		Healthcheck: `["CMD", "mongosh", "--quiet", "--eval", "db.adminCommand('ping')"]`,
+		Database:    true,
+	},
+	{
+		Name:        "redis",
+		Image:       "redis:alpine",
+		Ports:       []sailPort{{"FORWARD_REDIS_PORT", 6379, 6379}},
+		DataDir:     "/data",
+		Healthcheck: `["CMD", "redis-cli", "ping"]`,
+		Env:         []envChange{{Action: "set", Key: "REDIS_HOST", Value: "redis"}},
+	},
+	{
+		Name:        "valkey",
+		Image:       "valkey/valkey:alpine",
+		Ports:       []sailPort{{"FORWARD_VALKEY_PORT", 6379, 6379}},
+		DataDir:     "/data",
+		Healthcheck: `["CMD", "valkey-cli", "ping"]`,
+		Env:         []envChange{{Action: "set", Key: "REDIS_HOST", Value: "valkey"}},
+	},
+	{
+		Name:  "memcached",
+		Image: "memcached:alpine",
+		Ports: []sailPort{{"FORWARD_MEMCACHED_PORT", 11211, 11211}},
+		Env:   []envChange{{Action: "set", Key: "MEMCACHED_HOST", Value: "memcached"}},
+	},
+	{
+		Name:        "meilisearch",
+		Image:       "getmeili/meilisearch:latest",
+		Ports:       []sailPort{{"FORWARD_MEILISEARCH_PORT", 7700, 7700}},
+		Environment: [][2]string{{"MEILI_NO_ANALYTICS", "${MEILISEARCH_NO_ANALYTICS:-false}"}},
+		DataDir:     "/meili_data",
+		Healthcheck: `["CMD", "wget", "--no-verbose", "--spider", "http://127.0.0.1:7700/health"]`,
+		Env: []envChange{
+			{Action: "set", Key: "SCOUT_DRIVER", Value: "meilisearch"},
+			{Action: "set", Key: "MEILISEARCH_HOST", Value: "http://meilisearch:7700"},
+			{Action: "set", Key: "MEILISEARCH_NO_ANALYTICS", Value: "false"},
+		},
+	},
+	{
+		Name:  "typesense",
+		Image: "typesense/typesense:27.1",
+		Ports: []sailPort{{"FORWARD_TYPESENSE_PORT", 8108, 8108}},
+		Environment: [][2]string{
+			{"TYPESENSE_DATA_DIR", "${TYPESENSE_DATA_DIR:-/typesense-data}"},
+			{"TYPESENSE_API_KEY", "${TYPESENSE_API_KEY:-xyz}"},
+			{"TYPESENSE_ENABLE_CORS", "${TYPESENSE_ENABLE_CORS:-true}"},
+		},
+		DataDir:     "/typesense-data",
+		Healthcheck: `["CMD", "wget", "--no-verbose", "--spider", "http://localhost:8108/health"]`,
+		Env: []envChange{
+			{Action: "set", Key: "SCOUT_DRIVER", Value: "typesense"},
+			{Action: "set", Key: "TYPESENSE_HOST", Value: "typesense"},
+			{Action: "set", Key: "TYPESENSE_PORT", Value: "8108"},
+			{Action: "set", Key: "TYPESENSE_PROTOCOL", Value: "http"},
+			{Action: "set", Key: "TYPESENSE_API_KEY", Value: "xyz"},
+		},
+	},
+	{
+		Name:    "minio",
+		Image:   "minio/minio:latest",
+		Command: `minio server /data/minio --console-address ":8900"`,
+		Ports: []sailPort{
+			{"FORWARD_MINIO_PORT", 9000, 9000},
+			{"FORWARD_MINIO_CONSOLE_PORT", 8900, 8900},
+		},
+		Environment: [][2]string{
+			{"MINIO_ROOT_USER", "sail"},
+			{"MINIO_ROOT_PASSWORD", "password"},
+		},
+		DataDir:     "/data/minio",
+		Healthcheck: `["CMD", "mc", "ready", "local"]`,
+		Env: []envChange{
+			{Action: "set", Key: "FILESYSTEM_DISK", Value: "s3"},
+			{Action: "set", Key: "AWS_ACCESS_KEY_ID", Value: "sail"},
+			{Action: "set", Key: "AWS_SECRET_ACCESS_KEY", Value: "password"},
+			{Action: "set", Key: "AWS_BUCKET", Value: "local"},
+			{Action: "set", Key: "AWS_ENDPOINT", Value: "http://minio:9000"},
+			{Action: "set", Key: "AWS_USE_PATH_STYLE_ENDPOINT", Value: "true"},
+		},
+	},
+	{
+		Name:  "mailpit",
+		Image: "axllent/mailpit:latest",
+		Ports: []sailPort{
+			{"FORWARD_MAILPIT_PORT", 1025, 1025},
+			{"FORWARD_MAILPIT_DASHBOARD_PORT", 8025, 8025},
+		},
+		Env: []envChange{
+			{Action: "set", Key: "MAIL_MAILER", Value: "smtp"},
+			{Action: "set", Key: "MAIL_HOST", Value: "mailpit"},
+			{Action: "set", Key: "MAIL_PORT", Value: "1025"},
+		},
+	},
+}
+
+// sailAppPorts are published by the application container.
+var sailAppPorts = []sailPort{
+	{"APP_PORT", 80, 80},
+	{"VITE_PORT", 5173, 5173},
+}
+
+// sailPortAttempts bounds the search for a free host port.
+const sailPortAttempts = 100
+
+func lookupSailService(name string) *sailService {
+	for _, s := range sailServices {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func sailServiceNames() []string {
+	names := make([]string, len(sailServices))
+	for i, s := range sailServices {
+		names[i] = s.Name
+	}
+	return names
+}
+
+// sailEnabled reports whether `laravel new` generates a Sail environment.
+func sailEnabled() bool {
+	return sail || len(sailWith) > 0
+}
+
+// validateSailServices checks the --with and sail:add service names.
+func validateSailServices(names []string) error {
+	for _, name := range names {
+		if lookupSailService(name) == nil {
+			return fmt.Errorf("unknown service %q: possible values are: %s", name, strings.Join(sailServiceNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// selectSailServices returns the database service of the driver, if Sail has
+// one, followed by the requested services.
+func selectSailServices(driverName string, names []string) []*sailService {
+	var selected []*sailService
+	add := func(name string) {
+		s := lookupSailService(name)
+		if s == nil {
+			return
+		}
+		for _, existing := range selected {
+			if existing == s {
+				return
+			}
+		}
+		selected = append(selected, s)
+	}
+	if s := lookupSailService(driverName); s != nil && s.Database {
+		add(driverName)
+	}
+	for _, name := range names {
+		add(name)
+	}
+	return selected
+}
+
+// portEnv is the variable that changes the host port. Only the application's
+// own database uses FORWARD_DB_PORT, so a second database server does not
+// share it.
+func (s *sailService) portEnv(port sailPort, driverName string) string {
+	if port.Env == "FORWARD_DB_PORT" && s.Name != driverName {
+		return "FORWARD_" + strings.ToUpper(s.Name) + "_PORT"
+	}
+	return port.Env
+}
+
+// render writes the service as a block of the services section.
+func (s *sailService) render(driverName string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "    %s:\n", s.Name)
+	fmt.Fprintf(&b, "        image: '%s'\n", s.Image)
+	if s.Command != "" {
+		fmt.Fprintf(&b, "        command: '%s'\n", s.Command)
+	}
+	if len(s.Ports) > 0 {
+		b.WriteString("        ports:\n")
+		for _, port := range s.Ports {
+			fmt.Fprintf(&b, "            - '${%s:-%d}:%d'\n", s.portEnv(port, driverName), port.Default, port.Container)
+		}
+	}
+	if len(s.Environment) > 0 {
+		b.WriteString("        environment:\n")
+		for _, env := range s.Environment {
+			fmt.Fprintf(&b, "            %s: '%s'\n", env[0], env[1])
+		}
+	}
+	if s.DataDir != "" {
+		b.WriteString("        volumes:\n")
+		fmt.Fprintf(&b, "            - 'sail-%s:%s'\n", s.Name, s.DataDir)
+	}
+	b.WriteString("        networks:\n            - sail\n")
+	if s.Healthcheck != "" {
+		fmt.Fprintf(&b, "        healthcheck:\n            test: %s\n            retries: 3\n            timeout: 5s\n", s.Healthcheck)
+	}
+	return b.String()
+}
+
+// renderCompose writes a docker-compose.yml with the application container
+// and the services.
+func renderCompose(services []*sailService, driverName string) string {
+	var b strings.Builder
+	b.WriteString("services:\n")
+	b.WriteString("    laravel.test:\n")
+	b.WriteString("        build:\n")
+	fmt.Fprintf(&b, "            context: './vendor/laravel/sail/runtimes/%s'\n", sailRuntime)
+	b.WriteString("            dockerfile: Dockerfile\n")
+	b.WriteString("            args:\n")
+	b.WriteString("                WWWGROUP: '${WWWGROUP}'\n")
+	fmt.Fprintf(&b, "        image: 'sail-%s/app'\n", sailRuntime)
+	b.WriteString("        extra_hosts:\n")
+	b.WriteString("            - 'host.docker.internal:host-gateway'\n")
+	b.WriteString("        ports:\n")
+	b.WriteString("            - '${APP_PORT:-80}:80'\n")
+	b.WriteString("            - '${VITE_PORT:-5173}:${VITE_PORT:-5173}'\n")
+	b.WriteString("        environment:\n")
+	b.WriteString("            WWWUSER: '${WWWUSER}'\n")
+	b.WriteString("            LARAVEL_SAIL: 1\n")
+	b.WriteString("            XDEBUG_MODE: '${SAIL_XDEBUG_MODE:-off}'\n")
+	b.WriteString("            XDEBUG_CONFIG: '${SAIL_XDEBUG_CONFIG:-client_host=host.docker.internal}'\n")
+	b.WriteString("            IGNITION_LOCAL_SITES_PATH: '${PWD}'\n")
+	b.WriteString("        volumes:\n")
+	b.WriteString("            - '.:/var/www/html'\n")
+	b.WriteString("        networks:\n")
+	b.WriteString("            - sail\n")
+	if len(services) > 0 {
+		b.WriteString("        depends_on:\n")
+		for _, s := range services {
+			fmt.Fprintf(&b, "            - %s\n", s.Name)
+		}
+	}
+	for _, s := range services {
+		b.WriteString(s.render(driverName))
+	}
+	b.WriteString("networks:\n    sail:\n        driver: bridge\n")
+
+	volumes := ""
+	for _, s := range services {
+		if s.DataDir != "" {
+			volumes += fmt.Sprintf("    sail-%s:\n        driver: local\n", s.Name)
+		}
+	}
+	if volumes != "" {
+		b.WriteString("volumes:\n" + volumes)
+	}
+	return b.String()
+}
+
+// composeServiceNames lists the services of a docker-compose.yml.
+func composeServiceNames(data string) []string {
+	lines := strings.Split(data, "\n")
+	start, end := composeSection(lines, "services")
+	var names []string
+	for _, line := range lines[start:end] {
+		if strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     ") && strings.HasSuffix(line, ":") {
+			names = append(names, strings.TrimSuffix(strings.TrimSpace(line), ":"))
+		}
+	}
+	return names
+}
+
+// composeSection returns the lines of a top-level section, excluding its
+// header and trailing blank lines, or len(lines) twice when it is missing.
+func composeSection(lines []string, name string) (start, end int) {
+	start = -1
+	for i, line := range lines {
+		if line == name+":" {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return len(lines), len(lines)
+	}
+	end = start
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		if line != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "#") {
+			break
+		}
+		if strings.TrimSpace(line) != "" {
+			end = i + 1
+		}
+	}
+	return start, end
+}
+
+// addComposeServices adds the services to an existing docker-compose.yml,
+// keeping everything else as it is: the service blocks go at the end of the
+// services section, the application container depends on them and their
+// volumes are declared.
+func addComposeServices(data string, services []*sailService, driverName string) (string, error) {
+	lines := strings.Split(data, "\n")
+	start, end := composeSection(lines, "services")
+	if start == len(lines) {
+		return "", fmt.Errorf("%s has no services section", sailComposeFile)
+	}
+
+	var blocks []string
+	for _, s := range services {
+		blocks = append(blocks, strings.Split(strings.TrimSuffix(s.render(driverName), "\n"), "\n")...)
+	}
+	lines = insertLines(lines, end, blocks)
+
+	// The application waits for the new services
+	app := -1
+	for i := start; i < end; i++ {
+		if lines[i] == "    laravel.test:" {
+			app = i
+			break
+		}
+	}
+	if app >= 0 {
+		appEnd, dependsOn, lastDependency, mapForm := app+1, -1, -1, false
+		for ; appEnd < end; appEnd++ {
+			line := lines[appEnd]
+			if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "        ") {
+				break
+			}
+			if line == "        depends_on:" {
+				dependsOn, lastDependency = appEnd, appEnd
+			} else if strings.HasPrefix(line, "        depends_on:") {
+				return "", fmt.Errorf("%s: the depends_on of laravel.test is written on one line; split it over several lines first", sailComposeFile)
+			} else if dependsOn >= 0 && lastDependency == appEnd-1 && strings.HasPrefix(line, "            ") {
+				// Either a list of names or a map of names to conditions
+				lastDependency = appEnd
+				if rest := line[12:]; !strings.HasPrefix(rest, " ") && !strings.HasPrefix(rest, "-") && !strings.HasPrefix(rest, "#") {
+					mapForm = true
+				}
+			}
+		}
+		var dependencies []string
+		for _, s := range services {
+			if mapForm {
+				dependencies = append(dependencies, "            "+s.Name+":", "                condition: service_started")
+			} else {
+				dependencies = append(dependencies, "            - "+s.Name)
+			}
+		}
+		if dependsOn < 0 {
+			lines = insertLines(lines, appEnd, append([]string{"        depends_on:"}, dependencies...))
+		} else {
+			lines = insertLines(lines, lastDependency+1, dependencies)
+		}
+	}
+
+	var volumes []string
+	for _, s := range services {
+		if s.DataDir != "" {
+			volumes = append(volumes, "    sail-"+s.Name+":", "        driver: local")
+		}
+	}
+	if len(volumes) > 0 {
+		vStart, vEnd := composeSection(lines, "volumes")
+		if vStart == len(lines) {
+			for len(lines) > 0 && lines[len(lines)-1] == "" {
+				lines = lines[:len(lines)-1]
+			}
+			lines = append(append(lines, "volumes:"), volumes...)
+			lines = append(lines, "")
+		} else {
+			lines = insertLines(lines, vEnd, volumes)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func insertLines(lines []string, at int, inserted []string) []string {
+	result := make([]string, 0, len(lines)+len(inserted))
+	result = append(result, lines[:at]...)
+	result = append(result, inserted...)
+	return append(result, lines[at:]...)
+}
+
+// sailPortFree reports whether Docker can publish the host port. Binding a
+// port below 1024 takes privileges the installer usually lacks, so those are
+// probed by connecting instead.
+func sailPortFree(port int) bool {
+	if isPortAvailable(port) {
+		return true
+	}
+	if port >= 1024 {
+		return false
+	}
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), time.Second)
+	if err != nil {
+		return true
+	}
+	conn.Close()
+	return false
+}
+
+// forwardPort returns the first free host port from port on that no other
+// container uses.
+func forwardPort(port int, used map[int]bool) (int, error) {
+	for candidate := port; candidate < port+sailPortAttempts && candidate <= 65535; candidate++ {
+		if !used[candidate] && sailPortFree(candidate) {
+			used[candidate] = true
+			return candidate, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port found between %d and %d", port, port+sailPortAttempts-1)
+}
+
+// sailEnvChanges returns the .env edits that point the application at the
+// containers and move the published ports that are taken to free ones. env
+// is the current .env, so ports already chosen for existing containers are
+// kept free, and existing is the list of those containers.
+func sailEnvChanges(services []*sailService, existing []string, driver *databaseDriver, settings databaseSettings, env *dotenv.File) ([]envChange, error) {
+	var changes []envChange
+
+	// Ports published by the containers that are already there
+	used := map[int]bool{}
+	reserve := func(port sailPort, envKey string) {
+		if value, ok := env.Get(envKey); ok {
+			if p, err := strconv.Atoi(value); err == nil {
+				used[p] = true
+				return
+			}
+		}
+		used[port.Default] = true
+	}
+	var ports []sailPort
+	var portKeys []string
+	if len(existing) == 0 {
+		for _, port := range sailAppPorts {
+			ports, portKeys = append(ports, port), append(portKeys, port.Env)
+		}
+	} else {
+		for _, port := range sailAppPorts {
+			reserve(port, port.Env)
+		}
+	}
+	for _, name := range existing {
+		if s := lookupSailService(name); s != nil {
+			for _, port := range s.Ports {
+				reserve(port, s.portEnv(port, driver.Name))
+			}
+		}
+	}
+	for _, s := range services {
+		for _, port := range s.Ports {
+			ports, portKeys = append(ports, port), append(portKeys, s.portEnv(port, driver.Name))
+		}
+	}
+
+	for i, port := range ports {
+		chosen, err := forwardPort(port.Default, used)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", portKeys[i], err)
+		}
+		if chosen != port.Default {
+			changes = append(changes, envChange{Action: "set", Key: portKeys[i], Value: strconv.Itoa(chosen)})
+		}
+	}
+
+	for _, s := range services {
+		if !s.Database {
+			changes = append(changes, s.Env...)
+			continue
+		}
+		if s.Name != driver.Name {
+			continue
+		}
+		if driver.Keys != sqlEnvKeys {
+			// The URL of a driver configured by URL alone
+			if driver.Keys.URL == "" || len(driver.URLSchemes) == 0 {
+				continue
+			}
+			port := s.Ports[0].Container
+			changes = append(changes, envChange{Action: "set", Key: driver.Keys.URL, Value: fmt.Sprintf("%s://%s:%d", driver.URLSchemes[0], s.Name, port)})
+			continue
+		}
+		changes = append(changes,
+			envChange{Action: "set", Key: "DB_HOST", Value: s.Name},
+			envChange{Action: "set", Key: "DB_PORT", Value: strconv.Itoa(s.Ports[0].Container)},
+		)
+		// The images create a user of their own, which cannot be root
+		if settings.Username == "" || settings.Username == "root" {
+			changes = append(changes, envChange{Action: "set", Key: "DB_USERNAME", Value: "sail"})
+		}
+		if settings.Password == "" {
+			changes = append(changes, envChange{Action: "set", Key: "DB_PASSWORD", Value: "password", Secret: true})
+		}
+	}
+	return changes, nil
+}
+
+// installSail writes docker-compose.yml for the database driver and the
+// services, or adds the services to an existing one, and points .env at the
+// containers. It returns the services that were added.
+func installSail(tx *transaction, projectDir string, driver *databaseDriver, names []string) ([]string, error) {
+	composePath := filepath.Join(projectDir, sailComposeFile)
+	envPath := filepath.Join(projectDir, ".env")
+
+	data, err := os.ReadFile(composePath)
+	exists := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	existing := composeServiceNames(string(data))
+
+	var services []*sailService
+	for _, s := range selectSailServices(driver.Name, names) {
+		if !contains(existing, s.Name) {
+			services = append(services, s)
+		}
+	}
+	if exists && len(services) == 0 {
+		return nil, nil
+	}
+	if !exists {
+		existing = nil
+	}
+
+	env, err := dotenv.Load(envPath)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := loadDatabaseSettings(envPath, driver.Keys)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := sailEnvChanges(services, existing, driver, settings, env)
+	if err != nil {
+		return nil, err
+	}
+
+	compose := renderCompose(services, driver.Name)
+	if exists {
+		if compose, err = addComposeServices(string(data), services, driver.Name); err != nil {
+			return nil, err
+		}
+	}
+	if err := dotenv.WriteFile(composePath, []byte(compose)); err != nil {
+		return nil, err
+	}
+	if !exists && tx != nil {
+		tx.recordPath("remove "+composePath, composePath)
+	}
+	if err := applyEnvChanges(envPath, changes...); err != nil {
+		return nil, err
+	}
+
+	added := make([]string, len(services))
+	for i, s := range services {
+		added[i] = s.Name
+	}
+	return added, nil
+}
+
+// setupSail generates the Sail environment of a new application.
+func setupSail(ctx context.Context, tx *transaction, projectDir string, driver *databaseDriver, extra []string) error {
+	if driver.hasServer() && lookupSailService(driver.Name) == nil {
+		printWarning(ctx, fmt.Sprintf("Sail has no %s service; add one to %s yourself", driver.DisplayName, sailComposeFile))
+	}
+	added, err := installSail(tx, projectDir, driver, append(append([]string{}, sailWith...), extra...))
+	if err != nil {
+		return warn(ctx, exitPostInstall, err, "Could not generate the Sail environment")
+	}
+	if !quiet {
+		if len(added) > 0 {
+			fmt.Fprintf(stepStdout(ctx), "Wrote %s with %s\n", sailComposeFile, strings.Join(added, ", "))
+		} else {
+			fmt.Fprintf(stepStdout(ctx), "Wrote %s\n", sailComposeFile)
+		}
+	}
+	return nil
+}
+
+var sailAddCmd = &cobra.Command{
+	Use:   "sail:add [service...]",
+	Short: "Add Sail services to docker-compose.yml",
+	Long: "Add services to the docker-compose.yml of the Laravel application containing the current\n" +
+		"directory, creating the file when there is none, and point .env at the containers.\n\n" +
+		"The database server of DB_CONNECTION is added when Sail has a service for it. Host ports\n" +
+		"that are taken are replaced by free ones in .env.\n\n" +
+		"Services: " + strings.Join(sailServiceNames(), ", "),
+	Args:         usageArgs(cobra.ArbitraryArgs),
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateSailServices(args); err != nil {
+			return newExitError(exitUsage, err)
+		}
+		root, err := findProjectRoot()
+		if err != nil {
+			return err
+		}
+		env, err := dotenv.Load(filepath.Join(root, ".env"))
+		if err != nil {
+			return exitErrorf(exitFailure, "Failed to read the .env file: %v", err)
+		}
+		connection, _ := env.Get("DB_CONNECTION")
+		driver := lookupDriver(connection)
+		if driver == nil {
+			driver = &databaseDriver{Name: connection, DisplayName: connection, Keys: sqlEnvKeys}
+		}
+
+		_, statErr := os.Stat(filepath.Join(root, sailComposeFile))
+		added, err := installSail(nil, root, driver, args)
+		if err != nil {
+			return exitErrorf(exitFailure, "Could not update %s: %v", sailComposeFile, err)
+		}
+		switch {
+		case len(added) > 0:
+			fmt.Fprintf(stdout, "Added %s to %s\n", strings.Join(added, ", "), sailComposeFile)
+		case os.IsNotExist(statErr):
+			fmt.Fprintf(stdout, "Wrote %s\n", sailComposeFile)
+		default:
+			fmt.Fprintf(stdout, "%s already has these services\n", sailComposeFile)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sailAddCmd)
+}
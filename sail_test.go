@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"laravel-cli/dotenv"
+)
+
+func TestSailNewProject(t *testing.T) {
+	useFakeRunner(t, "")
+	quiet, noInteraction, database = true, true, "pgsql"
+	sailWith = []string{"redis", "mailpit"}
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	compose, err := readTestFile(filepath.Join("shop", sailComposeFile))
+	if err != nil {
+		t.Fatalf("Expected %s to be written: %v", sailComposeFile, err)
+	}
+	if names := composeServiceNames(compose); strings.Join(names, ",") != "laravel.test,pgsql,redis,mailpit" {
+		t.Errorf("Unexpected services %v", names)
+	}
+	for _, want := range []string{"            - pgsql\n            - redis\n            - mailpit\n", "'${FORWARD_DB_PORT:-5432}:5432'", "    sail-pgsql:\n        driver: local\n"} {
+		if !strings.Contains(compose, want) {
+			t.Errorf("Expected %s to contain %q, got:\n%s", sailComposeFile, want, compose)
+		}
+	}
+
+	env, _ := dotenv.Load(filepath.Join("shop", ".env"))
+	for key, want := range map[string]string{"DB_HOST": "pgsql", "DB_PORT": "5432", "DB_USERNAME": "sail", "REDIS_HOST": "redis", "MAIL_HOST": "mailpit"} {
+		if got, _ := env.Get(key); got != want {
+			t.Errorf("Expected %s=%s in .env, got %q", key, want, got)
+		}
+	}
+}
+
+func TestSailWithIsValidated(t *testing.T) {
+	useFakeRunner(t, "")
+	sailWith = []string{"redis", "rabbitmq"}
+
+	if err := createNewProject("shop"); exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), `unknown service "rabbitmq"`) {
+		t.Errorf("Expected a usage error for an unknown service, got %v", err)
+	}
+}
+
+func TestSailForwardsTakenPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+	taken := listener.Addr().(*net.TCPAddr).Port
+
+	used := map[int]bool{taken + 1: true}
+	port, err := forwardPort(taken, used)
+	if err != nil {
+		t.Fatalf("forwardPort returned error: %v", err)
+	}
+	if port == taken || port == taken+1 {
+		t.Errorf("Expected a port other than the taken ones, got %d", port)
+	}
+	if !used[port] {
+		t.Error("Expected the chosen port to be reserved")
+	}
+
+	redis := &sailService{Name: "redis", Ports: []sailPort{{"FORWARD_REDIS_PORT", taken, 6379}}}
+	env, _ := dotenv.Parse(nil)
+	changes, err := sailEnvChanges([]*sailService{redis}, []string{"pgsql"}, lookupDriver("pgsql"), databaseSettings{}, env)
+	if err != nil {
+		t.Fatalf("sailEnvChanges returned error: %v", err)
+	}
+	if len(changes) == 0 || changes[0].Key != "FORWARD_REDIS_PORT" || changes[0].Value == strconv.Itoa(taken) {
+		t.Errorf("Expected FORWARD_REDIS_PORT to move to a free port, got %+v", changes)
+	}
+}
+
+func TestAddComposeServicesKeepsTheFile(t *testing.T) {
+	existing := renderCompose([]*sailService{lookupSailService("mysql")}, "mysql")
+	existing = strings.Replace(existing, "    mysql:\n", "    mysql:\n        # Pinned for production parity\n", 1)
+
+	updated, err := addComposeServices(existing, []*sailService{lookupSailService("redis"), lookupSailService("pgsql")}, "mysql")
+	if err != nil {
+		t.Fatalf("addComposeServices returned error: %v", err)
+	}
+	if !strings.Contains(updated, "# Pinned for production parity") {
+		t.Error("Expected existing content to be kept")
+	}
+	if names := composeServiceNames(updated); strings.Join(names, ",") != "laravel.test,mysql,redis,pgsql" {
+		t.Errorf("Unexpected services %v", names)
+	}
+	for _, want := range []string{
+		"        depends_on:\n            - mysql\n            - redis\n            - pgsql\n",
+		"'${FORWARD_PGSQL_PORT:-5432}:5432'",
+		"    sail-mysql:\n        driver: local\n    sail-redis:\n        driver: local\n    sail-pgsql:\n        driver: local\n",
+	} {
+		if !strings.Contains(updated, want) {
+			t.Errorf("Expected the file to contain %q, got:\n%s", want, updated)
+		}
+	}
+
+	if _, err := addComposeServices("version: '3'\n", nil, "mysql"); err == nil {
+		t.Error("Expected a file without services to be rejected")
+	}
+}
+
+func TestAddComposeServicesToDependsOnMap(t *testing.T) {
+	existing := renderCompose([]*sailService{lookupSailService("mysql")}, "mysql")
+	existing = strings.Replace(existing, "        depends_on:\n            - mysql\n",
+		"        depends_on:\n            mysql:\n                condition: service_healthy\n", 1)
+
+	updated, err := addComposeServices(existing, []*sailService{lookupSailService("redis")}, "mysql")
+	if err != nil {
+		t.Fatalf("addComposeServices returned error: %v", err)
+	}
+	want := "        depends_on:\n            mysql:\n                condition: service_healthy\n" +
+		"            redis:\n                condition: service_started\n"
+	if !strings.Contains(updated, want) {
+		t.Errorf("Expected the file to contain %q, got:\n%s", want, updated)
+	}
+
+	inline := strings.Replace(existing, "        depends_on:\n            mysql:\n                condition: service_healthy\n",
+		"        depends_on: [mysql]\n", 1)
+	if _, err := addComposeServices(inline, []*sailService{lookupSailService("redis")}, "mysql"); err == nil {
+		t.Error("Expected a one-line depends_on to be rejected")
+	}
+}
+
+func TestSailAddCommand(t *testing.T) {
+	root := useEnvProject(t, "DB_CONNECTION=mysql\nDB_HOST=127.0.0.1\nDB_USERNAME=shop\nDB_PASSWORD=s3cret\n")
+	out := stdout.(*bytes.Buffer)
+
+	if err := sailAddCmd.RunE(sailAddCmd, []string{"meilisearch"}); err != nil {
+		t.Fatalf("sail:add returned error: %v", err)
+	}
+	if got := out.String(); got != "Added mysql, meilisearch to docker-compose.yml\n" {
+		t.Errorf("sail:add printed %q", got)
+	}
+	env, _ := dotenv.Load(filepath.Join(root, ".env"))
+	if user, _ := env.Get("DB_USERNAME"); user != "shop" {
+		t.Errorf("Expected the database user to be kept, got %q", user)
+	}
+	if password, _ := env.Get("DB_PASSWORD"); password != "s3cret" {
+		t.Errorf("Expected the database password to be kept, got %q", password)
+	}
+	if driver, _ := env.Get("SCOUT_DRIVER"); driver != "meilisearch" {
+		t.Errorf("Expected SCOUT_DRIVER=meilisearch, got %q", driver)
+	}
+
+	out.Reset()
+	if err := sailAddCmd.RunE(sailAddCmd, []string{"meilisearch"}); err != nil {
+		t.Fatalf("sail:add returned error: %v", err)
+	}
+	if !strings.Contains(out.String(), "already has these services") {
+		t.Errorf("Expected nothing to be added twice, got %q", out.String())
+	}
+	if err := sailAddCmd.RunE(sailAddCmd, []string{"rabbitmq"}); exitCodeFor(err) != exitUsage {
+		t.Errorf("Expected a usage error for an unknown service, got %v", err)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// scheduledStep is an installation step together with the steps it has to
+// wait for.
+type scheduledStep struct {
+	Name  string
+	After []string // Steps that must finish first; steps not in the schedule are ignored
+	Run   func(ctx context.Context) error
+	Skip  string // Why the step is skipped when Run is nil
+}
+
+// stepResult is sent by a step once it has finished.
+type stepResult struct {
+	step *scheduledStep
+	err  error
+}
+
+// runSchedule runs the steps, at most jobs at a time. A step starts once the
+// steps it comes after have finished or been skipped; steps that are ready at
+// the same time start in the order they are listed. When a step fails, the
+// running steps are allowed to finish, no new ones are started and the first
+// error is returned.
+func runSchedule(ctx context.Context, jobs int, steps []*scheduledStep) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	scheduled := map[string]bool{}
+	for _, step := range steps {
+		scheduled[step.Name] = true
+	}
+	done := map[string]bool{}
+	ready := func(step *scheduledStep) bool {
+		for _, name := range step.After {
+			if scheduled[name] && !done[name] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Only the steps that can overlap another one get prefixed output, so
+	// a schedule that runs one step at a time looks as it always has
+	prefixed := map[string]bool{}
+	if jobs > 1 {
+		prefixed = overlappingSteps(steps)
+	}
+
+	started := map[string]bool{}
+	finished := make(chan stepResult)
+	running := 0
+	var firstErr error
+	for {
+		for progress := firstErr == nil; progress; {
+			progress = false
+			for _, step := range steps {
+				if started[step.Name] || !ready(step) {
+					continue
+				}
+				if step.Run == nil {
+					started[step.Name], done[step.Name], progress = true, true, true
+					skipStep(step.Name, step.Skip)
+					continue
+				}
+				if running == jobs {
+					continue
+				}
+
+				started[step.Name], progress = true, true
+				running++
+				go func(step *scheduledStep, prefixed bool) {
+					finished <- stepResult{step, runScheduledStep(ctx, step, prefixed)}
+				}(step, prefixed[step.Name])
+			}
+		}
+
+		if running == 0 {
+			break
+		}
+		result := <-finished
+		running--
+		done[result.step.Name] = true
+		if result.err != nil && firstErr == nil {
+			firstErr = result.err
+		}
+	}
+
+	if firstErr == nil && len(started) < len(steps) {
+		var waiting []string
+		for _, step := range steps {
+			if !started[step.Name] {
+				waiting = append(waiting, step.Name)
+			}
+		}
+		return fmt.Errorf("the steps %v wait for each other", waiting)
+	}
+	return firstErr
+}
+
+func runScheduledStep(ctx context.Context, step *scheduledStep, prefixed bool) error {
+	if !prefixed {
+		return runStep(ctx, step.Name, step.Run)
+	}
+
+	output := &stepOutput{
+		stdout: &prefixWriter{w: stdout, prefix: "[" + step.Name + "] "},
+		stderr: &prefixWriter{w: os.Stderr, prefix: "[" + step.Name + "] "},
+	}
+	defer output.flush()
+	return runStep(context.WithValue(ctx, stepOutputKey{}, output), step.Name, step.Run)
+}
+
+// overlappingSteps returns the steps that run and could run at the same time
+// as another one, because neither waits for the other.
+func overlappingSteps(steps []*scheduledStep) map[string]bool {
+	byName := map[string]*scheduledStep{}
+	for _, step := range steps {
+		byName[step.Name] = step
+	}
+	var waitsFor func(step *scheduledStep, name string, seen map[string]bool) bool
+	waitsFor = func(step *scheduledStep, name string, seen map[string]bool) bool {
+		for _, dep := range step.After {
+			if dep == name {
+				return true
+			}
+			if other, ok := byName[dep]; ok && !seen[dep] {
+				seen[dep] = true
+				if waitsFor(other, name, seen) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	overlapping := map[string]bool{}
+	for i, a := range steps {
+		for _, b := range steps[i+1:] {
+			if a.Run == nil || b.Run == nil {
+				continue
+			}
+			if !waitsFor(a, b.Name, map[string]bool{}) && !waitsFor(b, a.Name, map[string]bool{}) {
+				overlapping[a.Name], overlapping[b.Name] = true, true
+			}
+		}
+	}
+	return overlapping
+}
+
+// stepOutput is where a step that runs alongside others writes its output.
+type stepOutput struct {
+	stdout, stderr *prefixWriter
+}
+
+type stepOutputKey struct{}
+
+func (o *stepOutput) flush() {
+	o.stdout.flush()
+	o.stderr.flush()
+}
+
+// stepStdout returns the writer for the messages of the current step.
+func stepStdout(ctx context.Context) io.Writer {
+	if output, ok := ctx.Value(stepOutputKey{}).(*stepOutput); ok {
+		return output.stdout
+	}
+	return stdout
+}
+
+// outputMu keeps the lines of steps that run at the same time from
+// interleaving.
+var outputMu sync.Mutex
+
+// prefixWriter writes whole lines to w, each starting with prefix.
+type prefixWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, data...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		p.writeLine(p.buf[:i+1])
+		p.buf = p.buf[i+1:]
+	}
+	return len(data), nil
+}
+
+// flush writes what is left of an unfinished line.
+func (p *prefixWriter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) > 0 {
+		p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	io.WriteString(p.w, p.prefix)
+	p.w.Write(line)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stepLog records the order in which steps finish.
+type stepLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *stepLog) add(entry string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+func (l *stepLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.entries, ",")
+}
+
+func TestRunScheduleOverlapsIndependentSteps(t *testing.T) {
+	log := &stepLog{}
+	aStarted, bStarted := make(chan struct{}), make(chan struct{})
+
+	// a and b only finish once the other one has started
+	waitFor := func(name string, started, other chan struct{}) func(context.Context) error {
+		return func(context.Context) error {
+			close(started)
+			select {
+			case <-other:
+			case <-time.After(5 * time.Second):
+				return fmt.Errorf("%s did not overlap", name)
+			}
+			log.add(name)
+			return nil
+		}
+	}
+	steps := []*scheduledStep{
+		{Name: "a", Run: waitFor("a", aStarted, bStarted)},
+		{Name: "b", Run: waitFor("b", bStarted, aStarted)},
+		{Name: "c", After: []string{"a", "b"}, Run: func(context.Context) error {
+			log.add("c")
+			return nil
+		}},
+	}
+
+	if err := runSchedule(context.Background(), 2, steps); err != nil {
+		t.Fatalf("runSchedule returned error: %v", err)
+	}
+	if got := log.String(); !strings.HasSuffix(got, ",c") || len(got) != 5 {
+		t.Errorf("Expected c to run after a and b, got %s", got)
+	}
+}
+
+func TestRunScheduleCapsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, most := 0, 0
+	log := &stepLog{}
+	step := func(name string) *scheduledStep {
+		return &scheduledStep{Name: name, Run: func(context.Context) error {
+			mu.Lock()
+			running++
+			if running > most {
+				most = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+			log.add(name)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return nil
+		}}
+	}
+
+	if err := runSchedule(context.Background(), 1, []*scheduledStep{step("a"), step("b"), step("c")}); err != nil {
+		t.Fatalf("runSchedule returned error: %v", err)
+	}
+	if most != 1 {
+		t.Errorf("Expected one step at a time, got %d", most)
+	}
+	if got := log.String(); got != "a,b,c" {
+		t.Errorf("Expected the steps in order, got %s", got)
+	}
+}
+
+func TestRunScheduleStopsAfterAFailure(t *testing.T) {
+	log := &stepLog{}
+	failed := make(chan struct{})
+	boom := errors.New("boom")
+	steps := []*scheduledStep{
+		{Name: "a", Run: func(context.Context) error {
+			defer close(failed)
+			return boom
+		}},
+		{Name: "b", Run: func(context.Context) error {
+			<-failed
+			log.add("b")
+			return nil
+		}},
+		{Name: "c", After: []string{"a"}, Run: func(context.Context) error {
+			log.add("c")
+			return nil
+		}},
+		{Name: "d", After: []string{"b"}, Run: func(context.Context) error {
+			log.add("d")
+			return nil
+		}},
+	}
+
+	if err := runSchedule(context.Background(), 2, steps); !errors.Is(err, boom) {
+		t.Fatalf("Expected the failure to be returned, got %v", err)
+	}
+	if got := log.String(); got != "b" {
+		t.Errorf("Expected the running step to finish and no new ones to start, got %s", got)
+	}
+}
+
+func TestRunScheduleSkippedAndMissingSteps(t *testing.T) {
+	log := &stepLog{}
+	steps := []*scheduledStep{
+		{Name: "a", After: []string{"preset"}, Skip: "not requested"},
+		{Name: "b", After: []string{"a"}, Run: func(context.Context) error {
+			log.add("b")
+			return nil
+		}},
+	}
+	if err := runSchedule(context.Background(), 2, steps); err != nil {
+		t.Fatalf("runSchedule returned error: %v", err)
+	}
+	if got := log.String(); got != "b" {
+		t.Errorf("Expected b to run after the skipped step, got %s", got)
+	}
+
+	cycle := []*scheduledStep{
+		{Name: "a", After: []string{"b"}, Run: func(context.Context) error { return nil }},
+		{Name: "b", After: []string{"a"}, Run: func(context.Context) error { return nil }},
+	}
+	if err := runSchedule(context.Background(), 2, cycle); err == nil || !strings.Contains(err.Error(), "wait for each other") {
+		t.Errorf("Expected a cycle to be reported, got %v", err)
+	}
+}
+
+func TestPrefixWriterKeepsLinesWhole(t *testing.T) {
+	var out bytes.Buffer
+	pest := &prefixWriter{w: &out, prefix: "[pest] "}
+	npm := &prefixWriter{w: &out, prefix: "[npm] "}
+
+	var wg sync.WaitGroup
+	for _, w := range []*prefixWriter{pest, npm} {
+		wg.Add(1)
+		go func(w *prefixWriter) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				fmt.Fprintf(w, "line %d", i)
+				fmt.Fprint(w, " done\n")
+			}
+			fmt.Fprint(w, "no newline")
+			w.flush()
+		}(w)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 102 {
+		t.Fatalf("Expected 102 lines, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !(strings.HasPrefix(line, "[pest] ") || strings.HasPrefix(line, "[npm] ")) || strings.Count(line, "]") != 1 {
+			t.Errorf("Unexpected line %q", line)
+		}
+	}
+}
+
+func TestPestAndFrontendRunTogether(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	quiet, noInteraction, pest, git, npm, strict = true, true, true, true, true, true
+
+	// composer update only succeeds once npm install has started
+	npmStarted := make(chan struct{})
+	var once sync.Once
+	fake.handler = func(cmd Command) (*Result, error) {
+		switch cmd.String() {
+		case "npm install":
+			once.Do(func() { close(npmStarted) })
+		case "composer update":
+			select {
+			case <-npmStarted:
+			case <-time.After(5 * time.Second):
+				return &Result{ExitCode: 1}, &CommandError{Command: cmd, ExitCode: 1}
+			}
+		}
+		return fakeSkeletonHandler(cmd)
+	}
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	commands := fake.commandLines()
+	position := map[string]int{}
+	for i, line := range commands {
+		position[line] = i
+	}
+	for _, line := range []string{"php ./vendor/bin/pest --init", "npm run build"} {
+		if position[line] > position["git init -q"] {
+			t.Errorf("Expected %q before git init, got:\n%s", line, strings.Join(commands, "\n"))
+		}
+	}
+
+	// The build reads vendor/, so it waits for Pest to finish with it
+	if position["npm run build"] < position["php ./vendor/bin/pest --init"] {
+		t.Errorf("Expected the frontend build after Pest, got:\n%s", strings.Join(commands, "\n"))
+	}
+}
+
+func TestJobsFlagRunsStepsInTurn(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	quiet, noInteraction, pest, npm, jobs = true, true, true, true, 1
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+	commands := strings.Join(fake.commandLines(), "\n")
+	if !strings.Contains(commands, "php ./vendor/bin/pest --init\nnpm install\nnpm run build") {
+		t.Errorf("Expected Pest and then npm, got:\n%s", commands)
+	}
+
+	useFakeRunner(t, "")
+	jobs = 0
+	if err := createNewProject("shop"); exitCodeFor(err) != exitUsage {
+		t.Errorf("Expected --jobs=0 to be rejected, got %v", err)
+	}
+}
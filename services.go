@@ -0,0 +1,475 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// appService is a part of the framework whose driver is chosen in .env:
+// the cache, queue, session, mail and broadcasting.
+type appService struct {
+	Key     string // The flag and the answers file key
+	Name    string
+	EnvKey  string
+	Flag    *string
+	Drivers []*serviceDriver // The skeleton's default first
+}
+
+// serviceDriver is a driver of an appService and what it needs.
+type serviceDriver struct {
+	Name       string
+	Packages   []string
+	Extensions []string
+	Redis      bool // Uses the Redis connection
+
+	// Env lists the keys the driver reads. They are added when missing and
+	// existing values are kept.
+	Env []envChange
+
+	// Generated keys get a random value when they are added.
+	Generated []string
+}
+
+var (
+	cacheStore          string
+	queueConnection     string
+	sessionDriver       string
+	mailMailer          string
+	broadcastConnection string
+)
+
+// awsEnv are the credentials of the AWS drivers.
+var awsEnv = []envChange{
+	{Action: "uncomment", Key: "AWS_ACCESS_KEY_ID", Value: ""},
+	{Action: "uncomment", Key: "AWS_SECRET_ACCESS_KEY", Value: ""},
+	{Action: "uncomment", Key: "AWS_DEFAULT_REGION", Value: "us-east-1"},
+}
+
+// redisEnv are the settings of the default Redis connection.
+var redisEnv = []envChange{
+	{Action: "uncomment", Key: "REDIS_HOST", Value: "127.0.0.1"},
+	{Action: "uncomment", Key: "REDIS_PASSWORD", Value: "null"},
+	{Action: "uncomment", Key: "REDIS_PORT", Value: "6379"},
+}
+
+var memcachedDriver = &serviceDriver{
+	Name:       "memcached",
+	Extensions: []string{"memcached"},
+	Env:        []envChange{{Action: "uncomment", Key: "MEMCACHED_HOST", Value: "127.0.0.1"}},
+}
+
+var appServices = []*appService{
+	{
+		Key: "cache", Name: "cache store", EnvKey: "CACHE_STORE", Flag: &cacheStore,
+		Drivers: []*serviceDriver{
+			{Name: "database"},
+			{Name: "file"},
+			{Name: "redis", Redis: true},
+			memcachedDriver,
+			{Name: "dynamodb", Packages: []string{"aws/aws-sdk-php"}, Env: append(append([]envChange{}, awsEnv...),
+				envChange{Action: "uncomment", Key: "DYNAMODB_CACHE_TABLE", Value: "cache"})},
+			{Name: "array"},
+		},
+	},
+	{
+		Key: "queue", Name: "queue connection", EnvKey: "QUEUE_CONNECTION", Flag: &queueConnection,
+		Drivers: []*serviceDriver{
+			{Name: "database"},
+			{Name: "sync"},
+			{Name: "redis", Redis: true},
+			{Name: "sqs", Packages: []string{"aws/aws-sdk-php"}, Env: append(append([]envChange{}, awsEnv...),
+				envChange{Action: "uncomment", Key: "SQS_PREFIX", Value: "https://sqs.us-east-1.amazonaws.com/your-account-id"},
+				envChange{Action: "uncomment", Key: "SQS_QUEUE", Value: "default"})},
+			{Name: "beanstalkd", Packages: []string{"pda/pheanstalk"}},
+		},
+	},
+	{
+		Key: "session", Name: "session driver", EnvKey: "SESSION_DRIVER", Flag: &sessionDriver,
+		Drivers: []*serviceDriver{
+			{Name: "database"},
+			{Name: "file"},
+			{Name: "cookie"},
+			{Name: "redis", Redis: true},
+			memcachedDriver,
+			{Name: "array"},
+		},
+	},
+	{
+		Key: "mail", Name: "mailer", EnvKey: "MAIL_MAILER", Flag: &mailMailer,
+		Drivers: []*serviceDriver{
+			{Name: "log"},
+			{Name: "smtp", Env: []envChange{
+				{Action: "uncomment", Key: "MAIL_HOST", Value: "127.0.0.1"},
+				{Action: "uncomment", Key: "MAIL_PORT", Value: "2525"},
+				{Action: "uncomment", Key: "MAIL_USERNAME", Value: "null"},
+				{Action: "uncomment", Key: "MAIL_PASSWORD", Value: "null"},
+			}},
+			{Name: "sendmail"},
+			{Name: "ses", Packages: []string{"aws/aws-sdk-php"}, Env: awsEnv},
+			{Name: "postmark", Packages: []string{"symfony/postmark-mailer", "symfony/http-client"}, Env: []envChange{
+				{Action: "uncomment", Key: "POSTMARK_TOKEN", Value: ""},
+			}},
+			{Name: "resend", Packages: []string{"resend/resend-php"}, Env: []envChange{
+				{Action: "uncomment", Key: "RESEND_KEY", Value: ""},
+			}},
+			{Name: "mailgun", Packages: []string{"symfony/mailgun-mailer", "symfony/http-client"}, Env: []envChange{
+				{Action: "uncomment", Key: "MAILGUN_DOMAIN", Value: ""},
+				{Action: "uncomment", Key: "MAILGUN_SECRET", Value: ""},
+				{Action: "uncomment", Key: "MAILGUN_ENDPOINT", Value: "api.mailgun.net"},
+			}},
+			{Name: "array"},
+		},
+	},
+	{
+		Key: "broadcast", Name: "broadcast connection", EnvKey: "BROADCAST_CONNECTION", Flag: &broadcastConnection,
+		Drivers: []*serviceDriver{
+			{Name: "log"},
+			{Name: "null"},
+			{Name: "reverb", Packages: []string{"laravel/reverb"}, Env: []envChange{
+				{Action: "uncomment", Key: "REVERB_HOST", Value: "localhost"},
+				{Action: "uncomment", Key: "REVERB_PORT", Value: "8080"},
+				{Action: "uncomment", Key: "REVERB_SCHEME", Value: "http"},
+			}, Generated: []string{"REVERB_APP_ID", "REVERB_APP_KEY", "REVERB_APP_SECRET"}},
+			{Name: "pusher", Packages: []string{"pusher/pusher-php-server"}, Env: []envChange{
+				{Action: "uncomment", Key: "PUSHER_APP_ID", Value: ""},
+				{Action: "uncomment", Key: "PUSHER_APP_KEY", Value: ""},
+				{Action: "uncomment", Key: "PUSHER_APP_SECRET", Value: ""},
+				{Action: "uncomment", Key: "PUSHER_HOST", Value: ""},
+				{Action: "uncomment", Key: "PUSHER_PORT", Value: "443"},
+				{Action: "uncomment", Key: "PUSHER_SCHEME", Value: "https"},
+				{Action: "uncomment", Key: "PUSHER_APP_CLUSTER", Value: "mt1"},
+			}},
+			{Name: "ably", Packages: []string{"ably/ably-php"}, Env: []envChange{
+				{Action: "uncomment", Key: "ABLY_KEY", Value: ""},
+			}},
+		},
+	},
+}
+
+func lookupAppService(key string) *appService {
+	for _, s := range appServices {
+		if s.Key == key {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s *appService) driver(name string) *serviceDriver {
+	for _, d := range s.Drivers {
+		if d.Name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+func (s *appService) driverNames() []string {
+	names := make([]string, len(s.Drivers))
+	for i, d := range s.Drivers {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// validateServiceDriver returns a validator for the drivers of a service.
+func validateServiceDriver(key string) func(string) error {
+	return func(value string) error {
+		s := lookupAppService(key)
+		if s.driver(value) == nil {
+			return fmt.Errorf("possible values are: %s", strings.Join(s.driverNames(), ", "))
+		}
+		return nil
+	}
+}
+
+// validateServiceDependencies checks the choices that depend on each other.
+// Only explicit choices are checked: the skeleton's own defaults work with
+// every database.
+func validateServiceDependencies(choices map[string]string, dbDriver string) error {
+	if d := lookupDriver(dbDriver); d != nil && !d.hasServer() && choices["queue"] == "database" {
+		return fmt.Errorf("the database queue needs a database server, but the database is %s: choose another --database or --queue", d.DisplayName)
+	}
+	return nil
+}
+
+// serviceFlagChoices returns the drivers given as flags.
+func serviceFlagChoices() map[string]string {
+	choices := map[string]string{}
+	for _, s := range appServices {
+		if *s.Flag != "" {
+			choices[s.Key] = *s.Flag
+		}
+	}
+	return choices
+}
+
+// validateServiceFlags checks the --cache, --queue, --session, --mail and
+// --broadcast values.
+func validateServiceFlags() error {
+	for _, s := range appServices {
+		if *s.Flag == "" {
+			continue
+		}
+		if err := validateServiceDriver(s.Key)(*s.Flag); err != nil {
+			return fmt.Errorf("invalid --%s %q: %v", s.Key, *s.Flag, err)
+		}
+	}
+	return nil
+}
+
+// checkServiceChoices validates the drivers given as flags or answers
+// against the database before anything is created, when the database is
+// already known.
+func checkServiceChoices(p *prompter) error {
+	dbDriver := database
+	if dbDriver == "" {
+		if answer, ok := p.prefilled("database"); ok {
+			dbDriver = answer
+		} else if !p.interactive {
+			dbDriver = "sqlite"
+		} else {
+			return nil
+		}
+	}
+
+	return validateServiceDependencies(givenServiceChoices(p), dbDriver)
+}
+
+// givenServiceChoices returns the drivers given as flags or in the answers
+// file, which are used without asking.
+func givenServiceChoices(p *prompter) map[string]string {
+	choices := serviceFlagChoices()
+	for _, s := range appServices {
+		if answer, ok := p.prefilled(s.Key); ok && choices[s.Key] == "" {
+			choices[s.Key] = answer
+		}
+	}
+	return choices
+}
+
+// chooseServices returns the chosen drivers: the flags, then the answers
+// file, then the prompts when the user wants to choose them.
+func chooseServices(p *prompter, dbDriver string) (map[string]string, error) {
+	choices := serviceFlagChoices()
+
+	open := false
+	for _, s := range appServices {
+		if _, given := choices[s.Key]; given {
+			continue
+		}
+		if value, ok := p.prefilled(s.Key); ok {
+			choices[s.Key] = value
+			p.record(s.Key, value)
+			continue
+		}
+		open = true
+	}
+
+	if open && askForConfirmation(p, "services", "Would you like to choose the cache, queue, session, mail and broadcasting drivers?") {
+		for _, s := range appServices {
+			if _, chosen := choices[s.Key]; chosen {
+				continue
+			}
+			if name := askForServiceDriver(p, s, dbDriver); name != "" {
+				choices[s.Key] = name
+			}
+		}
+	}
+
+	if err := validateServiceDependencies(choices, dbDriver); err != nil {
+		return nil, newExitError(exitUsage, err)
+	}
+	return choices, nil
+}
+
+// askForServiceDriver lists the drivers of the service and asks for one,
+// until it fits the other choices. Keeping the skeleton's default returns
+// an empty name, so .env is left as it is.
+func askForServiceDriver(p *prompter, s *appService, dbDriver string) string {
+	fmt.Fprintf(promptOut, "\nWhich %s will your application use?\n", s.Name)
+	for i, d := range s.Drivers {
+		fmt.Fprintf(promptOut, "%d) %s\n", i+1, d.Name)
+	}
+
+	for {
+		fmt.Fprintf(promptOut, "Please select (1-%d) [1]: ", len(s.Drivers))
+		input, err := p.readLine()
+		if err != nil {
+			fmt.Fprintln(promptOut)
+			return ""
+		}
+		if input == "" {
+			return "" // Keep the skeleton's default
+		}
+
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice < 1 || choice > len(s.Drivers) {
+			fmt.Fprintln(promptOut, "Please enter a valid number.")
+			continue
+		}
+		name := s.Drivers[choice-1].Name
+		if err := validateServiceDependencies(map[string]string{s.Key: name}, dbDriver); err != nil {
+			fmt.Fprintf(promptOut, "The %s %s cannot be used: %v\n", name, s.Name, err)
+			continue
+		}
+		p.record(s.Key, name)
+		return name
+	}
+}
+
+// chosenServiceDrivers returns the drivers of the choices in service order.
+func chosenServiceDrivers(choices map[string]string) []*serviceDriver {
+	var drivers []*serviceDriver
+	for _, s := range appServices {
+		if name, ok := choices[s.Key]; ok {
+			drivers = append(drivers, s.driver(name))
+		}
+	}
+	return drivers
+}
+
+// usesRedis reports whether one of the chosen drivers uses Redis.
+func usesRedis(choices map[string]string) bool {
+	for _, d := range chosenServiceDrivers(choices) {
+		if d.Redis {
+			return true
+		}
+	}
+	return false
+}
+
+// servicePackages lists the composer packages the chosen drivers need.
+func servicePackages(choices map[string]string, redisClient string) []string {
+	var packages []string
+	for _, d := range chosenServiceDrivers(choices) {
+		for _, pkg := range d.Packages {
+			if !contains(packages, pkg) {
+				packages = append(packages, pkg)
+			}
+		}
+	}
+	if redisClient == "predis" && usesRedis(choices) {
+		packages = append(packages, "predis/predis")
+	}
+	return packages
+}
+
+// serviceEnvChanges returns the .env edits for the chosen drivers. Generated
+// values are produced by generate, and kept out of .env.example.
+func serviceEnvChanges(choices map[string]string, redisClient string, generate func(key string) (string, error)) ([]envChange, error) {
+	var changes []envChange
+	for _, s := range appServices {
+		name, ok := choices[s.Key]
+		if !ok {
+			continue
+		}
+		changes = append(changes, envChange{Action: "set", Key: s.EnvKey, Value: name})
+		d := s.driver(name)
+		changes = append(changes, d.Env...)
+		for _, key := range d.Generated {
+			value, err := generate(key)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, envChange{Action: "uncomment", Key: key, Value: value, Secret: true})
+		}
+	}
+	if usesRedis(choices) {
+		changes = append(changes, envChange{Action: "set", Key: "REDIS_CLIENT", Value: redisClient})
+		changes = append(changes, redisEnv...)
+	}
+	return changes, nil
+}
+
+// generateServiceValue creates the Reverb application credentials.
+func generateServiceValue(key string) (string, error) {
+	if strings.HasSuffix(key, "_ID") {
+		n, err := rand.Int(rand.Reader, big.NewInt(900000))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprint(100000 + n.Int64()), nil
+	}
+	value, err := generatePassword(20)
+	return strings.ToLower(value), err
+}
+
+// configureServices applies the cache, queue, session, mail and broadcasting
+// choices: it writes their keys to .env and .env.example and installs the
+// packages they need. Redis is used through the phpredis extension when PHP
+// has it, and through predis otherwise.
+func configureServices(ctx context.Context, projectDir string, choices map[string]string) error {
+	if len(choices) == 0 {
+		return nil
+	}
+
+	var extensions []string
+	for _, d := range chosenServiceDrivers(choices) {
+		extensions = append(extensions, d.Extensions...)
+	}
+	if usesRedis(choices) {
+		extensions = append(extensions, "redis")
+	}
+	redisClient := "phpredis"
+	var missing []string
+	for _, extension := range missingExtensions(ctx, projectDir, extensions) {
+		if extension == "redis" {
+			redisClient = "predis"
+		} else if !contains(missing, extension) {
+			missing = append(missing, extension)
+		}
+	}
+	if len(missing) > 0 {
+		printWarning(ctx, fmt.Sprintf("The chosen drivers need the PHP extensions %s, which are not installed", strings.Join(missing, ", ")))
+	}
+
+	changes, err := serviceEnvChanges(choices, redisClient, generateServiceValue)
+	if err != nil {
+		return exitErrorf(exitProjectCreation, "could not configure the services: %w", err)
+	}
+	if err := applyEnvChanges(filepath.Join(projectDir, ".env"), changes...); err != nil {
+		return exitErrorf(exitProjectCreation, "could not configure the services: %w", err)
+	}
+	if err := applyEnvChanges(filepath.Join(projectDir, ".env.example"), exampleEnvChanges(changes)...); err != nil && !os.IsNotExist(err) {
+		return exitErrorf(exitProjectCreation, "could not configure the services: %w", err)
+	}
+
+	if packages := servicePackages(choices, redisClient); len(packages) > 0 {
+		if offline {
+			printWarning(ctx, fmt.Sprintf("--offline does not download packages; run composer require %s once online", strings.Join(packages, " ")))
+			return nil
+		}
+		if !quiet {
+			fmt.Fprintf(stepStdout(ctx), "Installing %s...\n", strings.Join(packages, ", "))
+		}
+		cmd := newCommand(projectDir, "composer", append([]string{"require"}, packages...)...)
+		if _, err := runCommand(ctx, cmd); err != nil {
+			if err := warn(ctx, exitPostInstall, err, "Could not install the packages of the chosen drivers"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// serviceSailServices are the Sail services the chosen drivers talk to.
+func serviceSailServices(choices map[string]string) []string {
+	var names []string
+	if usesRedis(choices) {
+		names = append(names, "redis")
+	}
+	if choices["cache"] == "memcached" || choices["session"] == "memcached" {
+		names = append(names, "memcached")
+	}
+	if choices["mail"] == "smtp" {
+		names = append(names, "mailpit")
+	}
+	return names
+}
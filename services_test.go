@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"laravel-cli/dotenv"
+)
+
+func TestServiceFlags(t *testing.T) {
+	fake := useFakeRunner(t, "")
+	fake.handler = func(cmd Command) (*Result, error) {
+		if cmd.Name == "php" && len(cmd.Args) == 1 && cmd.Args[0] == "-m" {
+			modules := append([]string{"[PHP Modules]", "Core", "pdo_pgsql"}, laravelExtensions...)
+			return &Result{Stdout: []byte(strings.Join(modules, "\n"))}, nil
+		}
+		return fakeSkeletonHandler(cmd)
+	}
+	quiet, noInteraction, database = true, true, "pgsql"
+	cacheStore, queueConnection, mailMailer, broadcastConnection = "redis", "database", "smtp", "reverb"
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	env, _ := dotenv.Load(filepath.Join("shop", ".env"))
+	for key, want := range map[string]string{
+		"CACHE_STORE":          "redis",
+		"QUEUE_CONNECTION":     "database",
+		"MAIL_MAILER":          "smtp",
+		"MAIL_PORT":            "2525",
+		"BROADCAST_CONNECTION": "reverb",
+		"REDIS_CLIENT":         "predis",
+		"REDIS_HOST":           "127.0.0.1",
+	} {
+		if got, _ := env.Get(key); got != want {
+			t.Errorf("Expected %s=%s in .env, got %q", key, want, got)
+		}
+	}
+	if secret, _ := env.Get("REVERB_APP_SECRET"); len(secret) != 20 {
+		t.Errorf("Expected a generated REVERB_APP_SECRET, got %q", secret)
+	}
+	example, _ := dotenv.Load(filepath.Join("shop", ".env.example"))
+	if secret, ok := example.Get("REVERB_APP_SECRET"); !ok || secret != "" {
+		t.Errorf("Expected an empty REVERB_APP_SECRET in .env.example, got %q", secret)
+	}
+	if _, ok := env.Get("SESSION_DRIVER"); ok {
+		t.Error("Expected the session driver to be left alone")
+	}
+
+	if !contains(fake.commandLines(), "composer require laravel/reverb predis/predis") {
+		t.Errorf("Expected the driver packages to be installed, got:\n%s", strings.Join(fake.commandLines(), "\n"))
+	}
+}
+
+func TestServiceFlagsAreValidated(t *testing.T) {
+	testCases := []struct {
+		setup func()
+		want  string
+	}{
+		{func() { cacheStore = "apc" }, `invalid --cache "apc": possible values are: database, file, redis`},
+		{func() { queueConnection = "database" }, "the database queue needs a database server, but the database is SQLite"},
+		{func() { database, queueConnection = "sqlite", "database" }, "the database queue needs a database server"},
+	}
+	for _, tc := range testCases {
+		useFakeRunner(t, "")
+		noInteraction = true
+		tc.setup()
+
+		err := createNewProject("shop")
+		if exitCodeFor(err) != exitUsage || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Expected a usage error containing %q, got %v", tc.want, err)
+		}
+		if _, err := os.Stat("shop"); !os.IsNotExist(err) {
+			t.Error("Expected nothing to be created")
+		}
+	}
+}
+
+func TestServicePrompts(t *testing.T) {
+	// SQLite, the default URL, no migrations, no npm, then redis for the
+	// cache, the database queue (refused for SQLite) and sync instead, the
+	// default session driver, smtp and the default broadcaster
+	useFakeRunner(t, "\n\nn\nn\ny\n3\n1\n2\n\n2\n\n")
+	quiet = true
+
+	if err := createNewProject("shop"); err != nil {
+		t.Fatalf("createNewProject returned error: %v", err)
+	}
+
+	env, _ := dotenv.Load(filepath.Join("shop", ".env"))
+	for key, want := range map[string]string{"CACHE_STORE": "redis", "QUEUE_CONNECTION": "sync", "MAIL_MAILER": "smtp", "REDIS_CLIENT": "phpredis"} {
+		if got, _ := env.Get(key); got != want {
+			t.Errorf("Expected %s=%s in .env, got %q", key, want, got)
+		}
+	}
+	for _, key := range []string{"SESSION_DRIVER", "BROADCAST_CONNECTION"} {
+		if _, ok := env.Get(key); ok {
+			t.Errorf("Expected %s to keep the skeleton's default", key)
+		}
+	}
+}
+
+func TestServiceSailServices(t *testing.T) {
+	choices := map[string]string{"session": "redis", "cache": "memcached", "mail": "smtp"}
+	if got := strings.Join(serviceSailServices(choices), ","); got != "redis,memcached,mailpit" {
+		t.Errorf("serviceSailServices() = %s; want redis,memcached,mailpit", got)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// gracePeriod is how long a child process gets to exit after the interrupt
+// has been forwarded to it before it is killed.
+var gracePeriod = 5 * time.Second
+
+// interruptError is the cancellation cause of the installer context when the
+// user presses Ctrl+C or the process receives SIGTERM.
+type interruptError struct {
+	signal os.Signal
+}
+
+func (e *interruptError) Error() string {
+	return "interrupted by " + e.signal.String()
+}
+
+// isInterrupted reports whether ctx was cancelled by a signal.
+func isInterrupted(ctx context.Context) bool {
+	var interrupt *interruptError
+	return errors.As(context.Cause(ctx), &interrupt)
+}
+
+// interruptSignal returns the signal that cancelled ctx, or SIGTERM when the
+// context ended for any other reason, such as a timeout.
+func interruptSignal(ctx context.Context) os.Signal {
+	var interrupt *interruptError
+	if errors.As(context.Cause(ctx), &interrupt) {
+		return interrupt.signal
+	}
+	return syscall.SIGTERM
+}
+
+// interruptHandler turns SIGINT/SIGTERM into context cancellation. Every
+// runner call made with its context forwards the signal to the child process
+// group, so cleanup happens after the running step has stopped.
+type interruptHandler struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	signals chan os.Signal
+	once    sync.Once
+	cleanup func()
+}
+
+func newInterruptHandler(parent context.Context, cleanup func()) *interruptHandler {
+	ctx, cancel := context.WithCancelCause(parent)
+	h := &interruptHandler{
+		ctx:     ctx,
+		cancel:  cancel,
+		signals: make(chan os.Signal, 2),
+		cleanup: cleanup,
+	}
+	signal.Notify(h.signals, os.Interrupt, syscall.SIGTERM)
+	go h.listen()
+	return h
+}
+
+func (h *interruptHandler) listen() {
+	sig, ok := <-h.signals
+	if !ok {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "\nInterrupted, stopping...")
+	h.cancel(&interruptError{signal: sig})
+
+	// The installer normally notices the cancelled context once the current
+	// step returns, cleans up and stops the handler. If it is stuck, for
+	// example waiting on a prompt, or the user presses Ctrl+C again, clean up
+	// and exit from here instead.
+	select {
+	case _, ok := <-h.signals:
+		if !ok {
+			return
+		}
+	case <-time.After(gracePeriod + 2*time.Second):
+	}
+	h.runCleanup()
+	restoreTerminal()
+	fmt.Fprintln(os.Stderr, "Goodbye!")
+	os.Exit(exitInterrupted)
+}
+
+// runCleanup runs the cleanup function at most once, no matter whether the
+// installer or the signal handler gets there first.
+func (h *interruptHandler) runCleanup() {
+	h.once.Do(func() {
+		if h.cleanup != nil {
+			h.cleanup()
+		}
+	})
+}
+
+// stop restores default signal handling.
+func (h *interruptHandler) stop() {
+	signal.Stop(h.signals)
+	close(h.signals)
+	h.cancel(nil)
+}
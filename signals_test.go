@@ -0,0 +1,67 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExecRunnerForwardsInterrupt(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(200*time.Millisecond, func() {
+		cancel(&interruptError{signal: syscall.SIGINT})
+	})
+
+	start := time.Now()
+	_, err := (&ExecRunner{GracePeriod: 5 * time.Second}).Run(ctx, helperCommand("sleep"))
+	if err == nil {
+		t.Fatal("Expected the interrupted command to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the child to stop on SIGINT, took %s", elapsed)
+	}
+
+	var interrupt *interruptError
+	if !errors.As(err, &interrupt) {
+		t.Errorf("Expected the error to carry the interrupt, got %v", err)
+	}
+}
+
+func TestExecRunnerKillsAfterGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(500*time.Millisecond, func() {
+		cancel(&interruptError{signal: syscall.SIGINT})
+	})
+
+	start := time.Now()
+	_, err := (&ExecRunner{GracePeriod: 300 * time.Millisecond}).Run(ctx, helperCommand("ignore-interrupt"))
+	if err == nil {
+		t.Fatal("Expected the killed command to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the child to be killed after the grace period, took %s", elapsed)
+	}
+}
+
+func TestInterruptSignal(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(&interruptError{signal: syscall.SIGTERM})
+
+	if !isInterrupted(ctx) {
+		t.Error("Expected context to be reported as interrupted")
+	}
+	if sig := interruptSignal(ctx); sig != syscall.SIGTERM {
+		t.Errorf("Expected SIGTERM, got %v", sig)
+	}
+
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancelTimeout()
+	<-timeout.Done()
+	if isInterrupted(timeout) {
+		t.Error("Expected a timeout not to count as an interrupt")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+)
+
+// disableEcho stops the terminal f from echoing input and returns the
+// function that turns it back on. stty is used because the syscalls differ
+// between Linux, macOS and the BSDs.
+func disableEcho(f *os.File) (func(), error) {
+	stty := func(arg string) error {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = f
+		return cmd.Run()
+	}
+	if err := stty("-echo"); err != nil {
+		return nil, err
+	}
+	return func() { stty("echo") }, nil
+}
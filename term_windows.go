@@ -0,0 +1,26 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+const enableEchoInput = 0x0004
+
+var setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// disableEcho stops the console f from echoing input and returns the
+// function that turns it back on.
+func disableEcho(f *os.File) (func(), error) {
+	handle := syscall.Handle(f.Fd())
+	var mode uint32
+	if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+		return nil, err
+	}
+	if ok, _, err := setConsoleMode.Call(uintptr(handle), uintptr(mode&^enableEchoInput)); ok == 0 {
+		return nil, err
+	}
+	return func() { setConsoleMode.Call(uintptr(handle), uintptr(mode)) }, nil
+}
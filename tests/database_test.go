@@ -1,78 +0,0 @@
-package tests
-
-import (
-	"testing"
-)
-
-func TestDatabaseConfiguration(t *testing.T) {
-	t.Run("TestSupportedDatabases", func(t *testing.T) {
-		supportedDatabases := []string{"mysql", "mariadb", "pgsql", "sqlite", "sqlsrv"}
-
-		for _, db := range supportedDatabases {
-			t.Run("Database_"+db, func(t *testing.T) {
-				// Test that each supported database is properly recognized
-				// This would test the contains() function with databaseDrivers slice
-				t.Skip("Requires import of main package functions")
-			})
-		}
-	})
-
-	t.Run("TestUnsupportedDatabases", func(t *testing.T) {
-		unsupportedDatabases := []string{"oracle", "mongodb", "redis", "cassandra", "neo4j"}
-
-		for _, db := range unsupportedDatabases {
-			t.Run("Database_"+db, func(t *testing.T) {
-				// Test that unsupported databases are rejected
-				t.Skip("Requires import of main package functions")
-			})
-		}
-	})
-
-	t.Run("TestGetAvailableDatabases", func(t *testing.T) {
-		// Test getAvailableDatabases() function
-		// This function checks which database drivers are actually available on the system
-		t.Skip("Requires system database driver detection")
-	})
-
-	t.Run("TestDatabaseConfiguration", func(t *testing.T) {
-		databases := []string{"mysql", "mariadb", "pgsql", "sqlite", "sqlsrv"}
-
-		for _, db := range databases {
-			t.Run("Configure_"+db, func(t *testing.T) {
-				// Test configureDatabaseConnection function
-				// This would test .env file modification for each database type
-				t.Skip("Integration test - requires temporary project setup")
-			})
-		}
-	})
-
-	t.Run("TestSQLiteSpecialHandling", func(t *testing.T) {
-		// Test SQLite-specific configuration
-		// SQLite has special handling for commenting/uncommenting database config
-		t.Skip("Integration test - requires .env file manipulation")
-	})
-
-	t.Run("TestDatabaseMigrations", func(t *testing.T) {
-		// Test runMigrations function
-		t.Skip("Integration test - requires Laravel project and database")
-	})
-}
-
-func TestDatabasePrompts(t *testing.T) {
-	t.Run("TestPromptForDatabase", func(t *testing.T) {
-		// Test promptForDatabase function with various inputs
-		inputs := []string{"1", "2", "3", "4", "5", "mysql", "sqlite", "invalid", ""}
-
-		for _, input := range inputs {
-			t.Run("Input_"+input, func(t *testing.T) {
-				// Test user input handling for database selection
-				t.Skip("Requires input simulation")
-			})
-		}
-	})
-
-	t.Run("TestDatabasePromptValidation", func(t *testing.T) {
-		// Test that invalid database selections are handled properly
-		t.Skip("Requires input simulation and validation testing")
-	})
-}
@@ -309,18 +309,6 @@ DB_CONNECTION=mysql`
 	})
 }
 
-func TestDatabaseSpecificEnvOperations(t *testing.T) {
-	t.Run("TestCommentDatabaseConfigForSQLite", func(t *testing.T) {
-		// Test commentDatabaseConfigForSQLite function
-		t.Skip("Requires import of main package functions")
-	})
-
-	t.Run("TestUncommentDatabaseConfig", func(t *testing.T) {
-		// Test uncommentDatabaseConfig function
-		t.Skip("Requires import of main package functions")
-	})
-}
-
 // Helper functions for testing
 func writeTestFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0644)
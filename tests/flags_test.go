@@ -1,142 +0,0 @@
-package tests
-
-import (
-	"os"
-	"testing"
-)
-
-// Test all command-line flags and their combinations
-func TestCommandFlags(t *testing.T) {
-	t.Run("TestDevFlag", func(t *testing.T) {
-		// Test --dev flag functionality
-		// This would test that dev version is selected when flag is set
-		t.Skip("Integration test - requires actual composer call")
-	})
-
-	t.Run("TestGitFlag", func(t *testing.T) {
-		// Test --git flag functionality
-		// This would test that git repository is initialized when flag is set
-		t.Skip("Integration test - requires git commands")
-	})
-
-	t.Run("TestBranchFlag", func(t *testing.T) {
-		// Test --branch flag functionality
-		// This would test that specified branch is created
-		t.Skip("Integration test - requires git commands")
-	})
-
-	t.Run("TestGithubFlag", func(t *testing.T) {
-		// Test --github flag functionality
-		// This would test GitHub repository creation
-		t.Skip("Integration test - requires GitHub API")
-	})
-
-	t.Run("TestOrganizationFlag", func(t *testing.T) {
-		// Test --organization flag functionality
-		// This would test GitHub organization repository creation
-		t.Skip("Integration test - requires GitHub API")
-	})
-
-	t.Run("TestDatabaseFlag", func(t *testing.T) {
-		// Test --database flag with valid values
-		validDatabases := []string{"mysql", "mariadb", "pgsql", "sqlite", "sqlsrv"}
-		for _, db := range validDatabases {
-			t.Run("Database_"+db, func(t *testing.T) {
-				// Test that each database option is accepted
-				// This would test database configuration setup
-				t.Skip("Integration test - requires project creation")
-			})
-		}
-	})
-
-	t.Run("TestInvalidDatabaseFlag", func(t *testing.T) {
-		// Test --database flag with invalid values
-		invalidDatabases := []string{"oracle", "mongodb", "redis"}
-		for _, db := range invalidDatabases {
-			t.Run("InvalidDatabase_"+db, func(t *testing.T) {
-				// Test that invalid database options are rejected
-				t.Skip("Integration test - requires command execution")
-			})
-		}
-	})
-
-	t.Run("TestStarterKitFlags", func(t *testing.T) {
-		// Test starter kit flags: --react, --vue, --livewire
-		starterKits := []string{"react", "vue", "livewire"}
-		for _, kit := range starterKits {
-			t.Run("StarterKit_"+kit, func(t *testing.T) {
-				// Test that each starter kit flag works
-				t.Skip("Integration test - requires project creation")
-			})
-		}
-	})
-
-	t.Run("TestLivewireClassComponentsFlag", func(t *testing.T) {
-		// Test --livewire-class-components flag
-		t.Skip("Integration test - requires project creation")
-	})
-
-	t.Run("TestWorkosFlag", func(t *testing.T) {
-		// Test --workos flag functionality
-		t.Skip("Integration test - requires project creation")
-	})
-
-	t.Run("TestTestingFrameworkFlags", func(t *testing.T) {
-		// Test --pest and --phpunit flags
-		frameworks := []string{"pest", "phpunit"}
-		for _, framework := range frameworks {
-			t.Run("TestingFramework_"+framework, func(t *testing.T) {
-				// Test that each testing framework flag works
-				t.Skip("Integration test - requires project creation")
-			})
-		}
-	})
-
-	t.Run("TestNpmFlag", func(t *testing.T) {
-		// Test --npm flag functionality
-		t.Skip("Integration test - requires npm commands")
-	})
-
-	t.Run("TestUsingFlag", func(t *testing.T) {
-		// Test --using flag with custom starter kit
-		t.Skip("Integration test - requires project creation")
-	})
-
-	t.Run("TestForceFlag", func(t *testing.T) {
-		// Test --force flag functionality
-		// Create a directory, then test that --force overwrites it
-		tempDir := "/tmp/test-laravel-force"
-		err := os.MkdirAll(tempDir, 0755)
-		if err != nil {
-			t.Fatalf("Failed to create test directory: %v", err)
-		}
-		defer os.RemoveAll(tempDir)
-
-		// Test that force flag allows overwriting existing directory
-		t.Skip("Integration test - requires full project creation")
-	})
-
-	t.Run("TestQuietFlag", func(t *testing.T) {
-		// Test --quiet flag functionality
-		// This would test that output is suppressed when flag is set
-		t.Skip("Integration test - requires output capture")
-	})
-}
-
-func TestFlagCombinations(t *testing.T) {
-	t.Run("TestGitAndGithubCombination", func(t *testing.T) {
-		// Test --git and --github flags together
-		t.Skip("Integration test - requires git and GitHub operations")
-	})
-
-	t.Run("TestMultipleStarterKitFlags", func(t *testing.T) {
-		// Test behavior when multiple starter kit flags are provided
-		// Should probably use the last one or show an error
-		t.Skip("Integration test - requires command execution")
-	})
-
-	t.Run("TestPestAndPhpunitCombination", func(t *testing.T) {
-		// Test behavior when both --pest and --phpunit are provided
-		t.Skip("Integration test - requires command execution")
-	})
-}
@@ -1,110 +0,0 @@
-package tests
-
-import (
-	"testing"
-)
-
-func TestGitOperations(t *testing.T) {
-	t.Run("TestInitializeGitRepository", func(t *testing.T) {
-		// Test initializeGitRepository function
-		t.Skip("Integration test - requires git commands and temporary directory")
-	})
-
-	t.Run("TestGetDefaultGitBranch", func(t *testing.T) {
-		// Test getDefaultGitBranch function
-		// Should return the default branch name (main, master, etc.)
-		t.Skip("Requires git configuration access")
-	})
-
-	t.Run("TestGitBranchCreation", func(t *testing.T) {
-		// Test custom branch creation when --branch flag is used
-		branches := []string{"develop", "feature/new-feature", "release/v1.0", "hotfix/urgent-fix"}
-
-		for _, branch := range branches {
-			t.Run("Branch_"+branch, func(t *testing.T) {
-				// Test that specified branch is created and checked out
-				t.Skip("Integration test - requires git commands")
-			})
-		}
-	})
-
-	t.Run("TestGitInitializationWithoutFlag", func(t *testing.T) {
-		// Test that git repository is NOT initialized when --git flag is not set
-		t.Skip("Integration test - requires project creation")
-	})
-
-	t.Run("TestGitInitializationWithFlag", func(t *testing.T) {
-		// Test that git repository IS initialized when --git flag is set
-		t.Skip("Integration test - requires project creation")
-	})
-}
-
-func TestGitHubOperations(t *testing.T) {
-	t.Run("TestCreateGitHubRepository", func(t *testing.T) {
-		// Test createGitHubRepository function
-		t.Skip("Integration test - requires GitHub API access and authentication")
-	})
-
-	t.Run("TestGitHubRepositoryWithOrganization", func(t *testing.T) {
-		// Test GitHub repository creation under an organization
-		t.Skip("Integration test - requires GitHub API access and organization permissions")
-	})
-
-	t.Run("TestGitHubRepositoryNaming", func(t *testing.T) {
-		// Test that GitHub repository names are properly formatted
-		projectNames := []string{
-			"my-project",
-			"my_project",
-			"MyProject",
-			"project123",
-		}
-
-		for _, name := range projectNames {
-			t.Run("Project_"+name, func(t *testing.T) {
-				// Test GitHub repository creation with different project names
-				t.Skip("Integration test - requires GitHub API")
-			})
-		}
-	})
-
-	t.Run("TestGitHubWithoutAuthentication", func(t *testing.T) {
-		// Test behavior when GitHub CLI is not authenticated
-		t.Skip("Integration test - requires environment manipulation")
-	})
-
-	t.Run("TestGitHubCommandAvailability", func(t *testing.T) {
-		// Test behavior when gh command is not available
-		t.Skip("Integration test - requires command availability testing")
-	})
-}
-
-func TestGitEdgeCases(t *testing.T) {
-	t.Run("TestGitInExistingRepository", func(t *testing.T) {
-		// Test git initialization in a directory that's already a git repository
-		t.Skip("Integration test - requires git repository setup")
-	})
-
-	t.Run("TestGitWithInvalidBranchName", func(t *testing.T) {
-		// Test behavior with invalid git branch names
-		invalidBranches := []string{
-			"branch with spaces",
-			"branch/with//double/slashes",
-			"-invalid-start",
-			"invalid-end-",
-			".invalid",
-			"invalid.",
-		}
-
-		for _, branch := range invalidBranches {
-			t.Run("InvalidBranch_"+branch, func(t *testing.T) {
-				// Test error handling for invalid branch names
-				t.Skip("Integration test - requires git command testing")
-			})
-		}
-	})
-
-	t.Run("TestGitRemoteOperations", func(t *testing.T) {
-		// Test git remote setup when GitHub repository is created
-		t.Skip("Integration test - requires git and GitHub operations")
-	})
-}
@@ -1,182 +0,0 @@
-package tests
-
-import (
-	"testing"
-)
-
-func TestIntegrationScenarios(t *testing.T) {
-	t.Run("TestCompleteProjectCreation", func(t *testing.T) {
-		// Test complete project creation with various flag combinations
-		scenarios := []struct {
-			name  string
-			flags []string
-		}{
-			{
-				"Basic",
-				[]string{},
-			},
-			{
-				"WithGit",
-				[]string{"--git"},
-			},
-			{
-				"WithDatabase",
-				[]string{"--database=sqlite"},
-			},
-			{
-				"WithReactKit",
-				[]string{"--react"},
-			},
-			{
-				"WithVueKit",
-				[]string{"--vue"},
-			},
-			{
-				"WithLivewireKit",
-				[]string{"--livewire"},
-			},
-			{
-				"WithPest",
-				[]string{"--pest"},
-			},
-			{
-				"WithNpm",
-				[]string{"--npm"},
-			},
-			{
-				"ComplexCombination",
-				[]string{"--git", "--database=mysql", "--react", "--pest", "--npm"},
-			},
-			{
-				"QuietMode",
-				[]string{"--quiet"},
-			},
-			{
-				"ForceOverwrite",
-				[]string{"--force"},
-			},
-		}
-
-		for _, scenario := range scenarios {
-			t.Run("Scenario_"+scenario.name, func(t *testing.T) {
-				// Test complete project creation with this scenario
-				t.Skip("Full integration test - requires complete environment setup")
-			})
-		}
-	})
-
-	t.Run("TestErrorScenarios", func(t *testing.T) {
-		// Test various error conditions
-		errorScenarios := []struct {
-			name        string
-			setup       func() error
-			expectError bool
-		}{
-			{
-				"ExistingDirectoryWithoutForce",
-				func() error {
-					// Create directory that already exists
-					return nil
-				},
-				true,
-			},
-			{
-				"InvalidProjectName",
-				func() error {
-					// Use invalid project name
-					return nil
-				},
-				true,
-			},
-			{
-				"MissingComposer",
-				func() error {
-					// Simulate missing composer
-					return nil
-				},
-				true,
-			},
-			{
-				"InvalidDatabase",
-				func() error {
-					// Use invalid database option
-					return nil
-				},
-				true,
-			},
-		}
-
-		for _, scenario := range errorScenarios {
-			t.Run("Error_"+scenario.name, func(t *testing.T) {
-				// Test error handling for this scenario
-				t.Skip("Integration test - requires error condition simulation")
-			})
-		}
-	})
-
-	t.Run("TestWorkflowScenarios", func(t *testing.T) {
-		// Test real-world workflow scenarios
-		workflows := []struct {
-			name        string
-			description string
-		}{
-			{
-				"SimpleWebApp",
-				"Create a simple Laravel web application with Vue.js frontend",
-			},
-			{
-				"APIProject",
-				"Create a Laravel API project with testing setup",
-			},
-			{
-				"FullStackProject",
-				"Create a full-stack project with React, database, Git, and testing",
-			},
-			{
-				"TeamProject",
-				"Create a project for team development with GitHub integration",
-			},
-		}
-
-		for _, workflow := range workflows {
-			t.Run("Workflow_"+workflow.name, func(t *testing.T) {
-				// Test realistic workflow scenarios
-				t.Skip("Workflow test - requires full environment and validation")
-			})
-		}
-	})
-}
-
-func TestPerformanceAndLimits(t *testing.T) {
-	t.Run("TestLargeProjectNames", func(t *testing.T) {
-		// Test performance with very long project names
-		t.Skip("Performance test - requires resource monitoring")
-	})
-
-	t.Run("TestManySimultaneousCreations", func(t *testing.T) {
-		// Test creating multiple projects simultaneously
-		t.Skip("Performance test - requires concurrency testing")
-	})
-
-	t.Run("TestResourceUsage", func(t *testing.T) {
-		// Test memory and CPU usage during project creation
-		t.Skip("Performance test - requires resource monitoring")
-	})
-}
-
-func TestCrossplatformCompatibility(t *testing.T) {
-	t.Run("TestWindowsPaths", func(t *testing.T) {
-		// Test Windows-style path handling
-		t.Skip("Cross-platform test - requires Windows environment")
-	})
-
-	t.Run("TestUnixPaths", func(t *testing.T) {
-		// Test Unix-style path handling
-		t.Skip("Cross-platform test - requires Unix environment")
-	})
-
-	t.Run("TestPermissions", func(t *testing.T) {
-		// Test file permission handling across platforms
-		t.Skip("Cross-platform test - requires permission testing")
-	})
-}
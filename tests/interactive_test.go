@@ -75,94 +75,3 @@ func TestPortOperations(t *testing.T) {
 		}
 	})
 }
-
-func TestUserInput(t *testing.T) {
-	t.Run("TestAskForPort", func(t *testing.T) {
-		// Test askForPort function with various inputs
-		testInputs := []struct {
-			input        string
-			defaultValue int
-			expected     int
-		}{
-			{"8080", 3000, 8080},
-			{"", 3000, 3000},
-			{"invalid", 3000, 3000},
-			{"0", 3000, 3000},
-			{"65536", 3000, 3000},
-			{"-1", 3000, 3000},
-		}
-
-		for _, ti := range testInputs {
-			t.Run("Input_"+ti.input, func(t *testing.T) {
-				// Test port input validation
-				t.Skip("Requires input simulation")
-			})
-		}
-	})
-
-	t.Run("TestAskForString", func(t *testing.T) {
-		// Test askForString function
-		testInputs := []struct {
-			input        string
-			defaultValue string
-			expected     string
-		}{
-			{"custom_value", "default", "custom_value"},
-			{"", "default", "default"},
-			{"   ", "default", "default"},
-			{"value with spaces", "default", "value with spaces"},
-		}
-
-		for _, ti := range testInputs {
-			t.Run("Input_"+ti.input, func(t *testing.T) {
-				// Test string input handling
-				t.Skip("Requires input simulation")
-			})
-		}
-	})
-
-	t.Run("TestAskForConfirmation", func(t *testing.T) {
-		// Test askForConfirmation function
-		testInputs := []struct {
-			input    string
-			expected bool
-		}{
-			{"y", true},
-			{"Y", true},
-			{"yes", true},
-			{"YES", true},
-			{"n", false},
-			{"N", false},
-			{"no", false},
-			{"NO", false},
-			{"", false},
-			{"invalid", false},
-		}
-
-		for _, ti := range testInputs {
-			t.Run("Input_"+ti.input, func(t *testing.T) {
-				// Test confirmation input handling
-				t.Skip("Requires input simulation")
-			})
-		}
-	})
-}
-
-func TestInteractiveSetup(t *testing.T) {
-	t.Run("TestRunInteractiveSetup", func(t *testing.T) {
-		// Test runInteractiveSetup function
-		// This function handles the interactive prompts for project configuration
-		t.Skip("Integration test - requires user interaction simulation")
-	})
-
-	t.Run("TestInteractiveSetupWithFlags", func(t *testing.T) {
-		// Test that interactive setup respects command-line flags
-		// e.g., if --database=mysql is provided, don't prompt for database
-		t.Skip("Integration test - requires flag state and interaction testing")
-	})
-
-	t.Run("TestInteractiveSetupQuietMode", func(t *testing.T) {
-		// Test that interactive setup is skipped in quiet mode
-		t.Skip("Integration test - requires quiet flag testing")
-	})
-}
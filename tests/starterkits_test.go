@@ -1,117 +0,0 @@
-package tests
-
-import (
-	"testing"
-)
-
-func TestStarterKits(t *testing.T) {
-	t.Run("TestGetStarterKitReact", func(t *testing.T) {
-		// Test getStarterKit() when react flag is true
-		// Should return "laravel/react-starter-kit"
-		t.Skip("Requires import of main package functions and flag manipulation")
-	})
-
-	t.Run("TestGetStarterKitVue", func(t *testing.T) {
-		// Test getStarterKit() when vue flag is true
-		// Should return "laravel/vue-starter-kit"
-		t.Skip("Requires import of main package functions and flag manipulation")
-	})
-
-	t.Run("TestGetStarterKitLivewire", func(t *testing.T) {
-		// Test getStarterKit() when livewire flag is true
-		// Should return "laravel/livewire-starter-kit"
-		t.Skip("Requires import of main package functions and flag manipulation")
-	})
-
-	t.Run("TestGetStarterKitCustom", func(t *testing.T) {
-		// Test getStarterKit() when using flag is set
-		customKits := []string{
-			"custom/starter-kit",
-			"organization/custom-kit",
-			"github-user/my-starter",
-		}
-
-		for _, kit := range customKits {
-			t.Run("Custom_"+kit, func(t *testing.T) {
-				// Test that custom starter kit is returned correctly
-				t.Skip("Requires import of main package functions and flag manipulation")
-			})
-		}
-	})
-
-	t.Run("TestGetStarterKitNone", func(t *testing.T) {
-		// Test getStarterKit() when no starter kit flags are set
-		// Should return empty string
-		t.Skip("Requires import of main package functions")
-	})
-
-	t.Run("TestIsLaravelStarterKit", func(t *testing.T) {
-		laravelKits := []string{
-			"laravel/react-starter-kit",
-			"laravel/vue-starter-kit",
-			"laravel/livewire-starter-kit",
-		}
-
-		for _, kit := range laravelKits {
-			t.Run("Laravel_"+kit, func(t *testing.T) {
-				// Test isLaravelStarterKit() returns true for Laravel official kits
-				t.Skip("Requires import of main package functions")
-			})
-		}
-	})
-
-	t.Run("TestIsNotLaravelStarterKit", func(t *testing.T) {
-		nonLaravelKits := []string{
-			"custom/starter-kit",
-			"organization/custom-kit",
-			"github-user/my-starter",
-			"",
-		}
-
-		for _, kit := range nonLaravelKits {
-			t.Run("NonLaravel_"+kit, func(t *testing.T) {
-				// Test isLaravelStarterKit() returns false for non-Laravel kits
-				t.Skip("Requires import of main package functions")
-			})
-		}
-	})
-
-	t.Run("TestStarterKitPriority", func(t *testing.T) {
-		// Test behavior when multiple starter kit flags are set
-		// What happens if both --react and --vue are specified?
-		// What happens if --using is specified along with other flags?
-		t.Skip("Requires flag conflict testing")
-	})
-}
-
-func TestStarterKitInstallation(t *testing.T) {
-	t.Run("TestCreateLaravelProject", func(t *testing.T) {
-		// Test createLaravelProject function with different starter kits
-		starterKits := []string{
-			"", // no starter kit
-			"laravel/react-starter-kit",
-			"laravel/vue-starter-kit",
-			"laravel/livewire-starter-kit",
-			"custom/starter-kit",
-		}
-
-		for _, kit := range starterKits {
-			t.Run("Create_"+kit, func(t *testing.T) {
-				// Test project creation with each starter kit
-				t.Skip("Integration test - requires composer and actual project creation")
-			})
-		}
-	})
-
-	t.Run("TestStarterKitWithVersion", func(t *testing.T) {
-		// Test starter kit installation with different Laravel versions
-		versions := []string{"", "^10.0", "^11.0", "dev-master"}
-
-		for _, version := range versions {
-			t.Run("Version_"+version, func(t *testing.T) {
-				// Test starter kit with specific Laravel version
-				t.Skip("Integration test - requires composer")
-			})
-		}
-	})
-}
@@ -1,150 +0,0 @@
-package tests
-
-import (
-	"testing"
-)
-
-func TestUtilityFunctions(t *testing.T) {
-	t.Run("TestContains", func(t *testing.T) {
-		// Test contains function with string slices
-		_ = []string{"apple", "banana", "cherry", "date"}
-
-		testCases := []struct {
-			item     string
-			expected bool
-		}{
-			{"apple", true},
-			{"banana", true},
-			{"cherry", true},
-			{"date", true},
-			{"grape", false},
-			{"", false},
-			{"APPLE", false}, // case sensitive
-		}
-
-		for _, tc := range testCases {
-			t.Run("Contains_"+tc.item, func(t *testing.T) {
-				// result := contains(testSlice, tc.item)
-				// if result != tc.expected {
-				//     t.Errorf("contains(%v, %s) = %v; want %v", testSlice, tc.item, result, tc.expected)
-				// }
-				t.Skip("Requires import of main package functions")
-			})
-		}
-	})
-
-	t.Run("TestContainsEmptySlice", func(t *testing.T) {
-		// Test contains function with empty slice
-		_ = []string{}
-
-		// result := contains(emptySlice, "anything")
-		// if result != false {
-		//     t.Errorf("contains([], \"anything\") = %v; want false", result)
-		// }
-		t.Skip("Requires import of main package functions")
-	})
-
-	t.Run("TestGetVersion", func(t *testing.T) {
-		// Test getVersion function
-		// This function returns Laravel version based on dev flag
-		t.Skip("Requires import of main package functions and flag testing")
-	})
-
-	t.Run("TestGetVersionDev", func(t *testing.T) {
-		// Test getVersion function when dev flag is true
-		// Should return "dev-master"
-		t.Skip("Requires import of main package functions and flag manipulation")
-	})
-
-	t.Run("TestGetVersionStable", func(t *testing.T) {
-		// Test getVersion function when dev flag is false
-		// Should return empty string (latest stable)
-		t.Skip("Requires import of main package functions and flag manipulation")
-	})
-}
-
-func TestFileOperations(t *testing.T) {
-	t.Run("TestReplaceInFile", func(t *testing.T) {
-		// Test replaceInFile function
-		// This function replaces text in a file
-		t.Skip("Integration test - requires file manipulation")
-	})
-
-	t.Run("TestCopyFile", func(t *testing.T) {
-		// Test copyFile function
-		// This function copies files
-		t.Skip("Integration test - requires file operations")
-	})
-
-	t.Run("TestReplaceInFileNonExistent", func(t *testing.T) {
-		// Test replaceInFile with non-existent file
-		t.Skip("Integration test - requires error handling testing")
-	})
-
-	t.Run("TestCopyFileNonExistent", func(t *testing.T) {
-		// Test copyFile with non-existent source file
-		t.Skip("Integration test - requires error handling testing")
-	})
-}
-
-func TestToolRequirements(t *testing.T) {
-	t.Run("TestEnsureRequiredTools", func(t *testing.T) {
-		// Test ensureRequiredTools function
-		// This function checks if required tools (composer, php, etc.) are available
-		t.Skip("Integration test - requires system tool checking")
-	})
-
-	t.Run("TestRequiredToolsAvailability", func(t *testing.T) {
-		// Test individual tool availability
-		tools := []string{"composer", "php", "git", "gh"}
-
-		for _, tool := range tools {
-			t.Run("Tool_"+tool, func(t *testing.T) {
-				// Test that each required tool is available
-				t.Skip("Integration test - requires system command checking")
-			})
-		}
-	})
-}
-
-func TestPestInstallation(t *testing.T) {
-	t.Run("TestInstallPest", func(t *testing.T) {
-		// Test installPest function
-		t.Skip("Integration test - requires composer and Laravel project")
-	})
-
-	t.Run("TestInstallPestWithoutProject", func(t *testing.T) {
-		// Test installPest function when no Laravel project exists
-		t.Skip("Integration test - requires error handling testing")
-	})
-}
-
-func TestNpmOperations(t *testing.T) {
-	t.Run("TestRunNpmCommands", func(t *testing.T) {
-		// Test runNpmCommands function
-		t.Skip("Integration test - requires npm and Laravel project")
-	})
-
-	t.Run("TestNpmCommandsWithoutNpm", func(t *testing.T) {
-		// Test runNpmCommands when npm is not available
-		t.Skip("Integration test - requires environment manipulation")
-	})
-}
-
-func TestOutputFunctions(t *testing.T) {
-	t.Run("TestPrintLaravelLogo", func(t *testing.T) {
-		// Test printLaravelLogo function
-		// This function prints the Laravel ASCII logo
-		t.Skip("Output test - requires output capture")
-	})
-
-	t.Run("TestPrintCompletionMessage", func(t *testing.T) {
-		// Test printCompletionMessage function
-		t.Skip("Output test - requires output capture")
-	})
-
-	t.Run("TestQuietModeOutput", func(t *testing.T) {
-		// Test that output is suppressed when quiet flag is true
-		t.Skip("Output test - requires quiet flag and output capture")
-	})
-}